@@ -0,0 +1,388 @@
+package slogger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CommonLogFormat is the Apache Common Log Format, also known as NCSA Common.
+	CommonLogFormat = `%h %l %u %t "%r" %>s %b`
+	// NCSALogFormat is an alias of CommonLogFormat.
+	NCSALogFormat = CommonLogFormat
+	// CombinedLogFormat is the Apache Combined Log Format, also known as NCSA Combined.
+	CombinedLogFormat = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"`
+)
+
+// DefaultW3CFields is the field list used by NewW3CWriter when no fields are given.
+var DefaultW3CFields = []string{"date", "time", "c-ip", "cs-method", "cs-uri-stem", "cs-uri-query", "sc-status", "sc-bytes", "time-taken", "cs(User-Agent)", "cs(Referer)"}
+
+// apacheTimeFormat is the layout of the Apache %t directive.
+const apacheTimeFormat = "[02/Jan/2006:15:04:05 -0700]"
+
+// accessLogField renders a single directive or literal of an access log line.
+type accessLogField func(buf *bytes.Buffer, v logEntry)
+
+// AccessLogWriter renders realtime entries as access log lines and writes them to an io.Writer.
+// Aggregated entries are not written. It is safe for concurrent use.
+type AccessLogWriter struct {
+	mu           sync.Mutex
+	w            io.Writer
+	fields       []accessLogField
+	headers      []string
+	preamble     string
+	preambleDone bool
+}
+
+// WithAccessLog adds access log writers receiving every realtime entry, in addition to the slog output.
+func WithAccessLog(writers ...*AccessLogWriter) Option {
+	return func(c *conf) {
+		c.accessLogs = append(c.accessLogs, writers...)
+	}
+}
+
+// NewAccessLogWriter returns an AccessLogWriter using an Apache style format string, eg: CommonLogFormat or CombinedLogFormat.
+// Supported directives: %h %a %A %l %u %t %r %s %>s %b %B %D %T %m %U %q %H %{Header}i and %%.
+// As in Apache, %r contains the query string, and quotes, backslashes and control characters of request values are
+// escaped as \", \\ and \xhh.
+func NewAccessLogWriter(w io.Writer, format string) (*AccessLogWriter, error) {
+	fields, headers, err := parseApacheFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	return &AccessLogWriter{w: w, fields: fields, headers: headers}, nil
+}
+
+// NewW3CWriter returns an AccessLogWriter using the W3C Extended Log File Format with the given fields, or DefaultW3CFields.
+// The #Version and #Fields directives are written before the first line. Values are escaped as by NewAccessLogWriter,
+// then spaces are replaced by "+". s-ip is the local address of the server that accepted the connection.
+// Supported fields: date, time, c-ip, s-ip, cs-method, cs-uri-stem, cs-uri-query, cs-uri, cs-version, sc-status, sc-bytes,
+// time-taken and cs(Header).
+func NewW3CWriter(w io.Writer, fields ...string) (*AccessLogWriter, error) {
+	if len(fields) == 0 {
+		fields = DefaultW3CFields
+	}
+	a := &AccessLogWriter{
+		w:        w,
+		preamble: "#Version: 1.0\n#Fields: " + strings.Join(fields, " ") + "\n",
+	}
+	for i, name := range fields {
+		field, header, err := w3cField(name)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			a.fields = append(a.fields, literalField(" "))
+		}
+		a.fields = append(a.fields, field)
+		if header != "" {
+			a.headers = append(a.headers, header)
+		}
+	}
+	return a, nil
+}
+
+// Emit writes a realtime entry, so that an AccessLogWriter can also be used as a Sink.
+func (a *AccessLogWriter) Emit(e Entry) error {
+	return a.write(e.e)
+}
+
+// write renders a realtime entry and writes it.
+func (a *AccessLogWriter) write(v logEntry) error {
+	if v.isAggregate {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, field := range a.fields {
+		field(&buf, v)
+	}
+	buf.WriteByte('\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.preamble != "" && !a.preambleDone {
+		if _, err := io.WriteString(a.w, a.preamble); err != nil {
+			return err
+		}
+		a.preambleDone = true
+	}
+	_, err := a.w.Write(buf.Bytes())
+	return err
+}
+
+// accessLogHeaders returns the request headers required by the configured access log writers.
+func (c *conf) accessLogHeaders() []string {
+	var headers []string
+	for _, a := range c.accessLogs {
+		headers = append(headers, a.headers...)
+	}
+	for _, w := range c.sinks {
+		switch s := w.sink.(type) {
+		case *AccessLogWriter:
+			headers = append(headers, s.headers...)
+		case *FileSink:
+			if a, ok := s.encoder.(*AccessLogWriter); ok {
+				headers = append(headers, a.headers...)
+			}
+		}
+	}
+	return headers
+}
+
+// captureHeaders returns the values of the given headers, keyed by canonical name.
+func captureHeaders(header http.Header, names []string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	captured := make(map[string]string, len(names))
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			captured[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	return captured
+}
+
+// requestHeader returns a request header of an entry, using the dedicated fields for Referer and User-Agent.
+func requestHeader(v logEntry, name string) string {
+	switch http.CanonicalHeaderKey(name) {
+	case "Referer":
+		return v.referer
+	case "User-Agent":
+		return v.ua
+	}
+	return v.accessHeaders[http.CanonicalHeaderKey(name)]
+}
+
+// parseApacheFormat compiles an Apache LogFormat string into fields, returning the request headers it references.
+func parseApacheFormat(format string) (fields []accessLogField, headers []string, err error) {
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			fields = append(fields, literalField(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(format); i++ {
+		ch := format[i]
+		if ch != '%' {
+			literal.WriteByte(ch)
+			continue
+		}
+		i++
+		if i >= len(format) {
+			return nil, nil, fmt.Errorf("access log format %q: trailing %%", format)
+		}
+		// Skip the < and > modifiers, eg: %>s
+		for i < len(format) && (format[i] == '>' || format[i] == '<') {
+			i++
+		}
+		if i >= len(format) {
+			return nil, nil, fmt.Errorf("access log format %q: incomplete directive", format)
+		}
+
+		var param string
+		if format[i] == '{' {
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return nil, nil, fmt.Errorf("access log format %q: unterminated %%{", format)
+			}
+			param = format[i+1 : i+end]
+			i += end + 1
+			if i >= len(format) {
+				return nil, nil, fmt.Errorf("access log format %q: missing directive after %%{%s}", format, param)
+			}
+		}
+
+		if format[i] == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+		field, err := apacheField(format[i], param)
+		if err != nil {
+			return nil, nil, fmt.Errorf("access log format %q: %w", format, err)
+		}
+		if format[i] == 'i' {
+			headers = append(headers, param)
+		}
+		flush()
+		fields = append(fields, field)
+	}
+	flush()
+	return fields, headers, nil
+}
+
+// apacheField returns the field rendering an Apache directive.
+func apacheField(directive byte, param string) (accessLogField, error) {
+	switch directive {
+	case 'h', 'a':
+		return stringField(func(v logEntry) string { return v.ip }), nil
+	case 'A':
+		return stringField(func(v logEntry) string { return v.localIp }), nil
+	case 'l', 'u':
+		return literalField("-"), nil
+	case 't':
+		return func(buf *bytes.Buffer, v logEntry) {
+			buf.WriteString(v.created.Format(apacheTimeFormat))
+		}, nil
+	case 'r':
+		return func(buf *bytes.Buffer, v logEntry) {
+			writeEscaped(buf, v.method)
+			buf.WriteByte(' ')
+			writeEscaped(buf, v.path)
+			if v.queryString != "" {
+				buf.WriteByte('?')
+				writeEscaped(buf, v.queryString)
+			}
+			buf.WriteByte(' ')
+			writeEscaped(buf, v.proto)
+		}, nil
+	case 's':
+		return func(buf *bytes.Buffer, v logEntry) {
+			buf.WriteString(strconv.Itoa(v.statusCode))
+		}, nil
+	case 'b':
+		return func(buf *bytes.Buffer, v logEntry) {
+			if v.responseBodySize == 0 {
+				buf.WriteByte('-')
+				return
+			}
+			buf.WriteString(strconv.Itoa(v.responseBodySize))
+		}, nil
+	case 'B':
+		return func(buf *bytes.Buffer, v logEntry) {
+			buf.WriteString(strconv.Itoa(v.responseBodySize))
+		}, nil
+	case 'D':
+		return func(buf *bytes.Buffer, v logEntry) {
+			buf.WriteString(strconv.FormatInt(v.latency.Microseconds(), 10))
+		}, nil
+	case 'T':
+		return func(buf *bytes.Buffer, v logEntry) {
+			buf.WriteString(strconv.FormatInt(int64(v.latency/time.Second), 10))
+		}, nil
+	case 'm':
+		return stringField(func(v logEntry) string { return v.method }), nil
+	case 'U':
+		return stringField(func(v logEntry) string { return v.path }), nil
+	case 'q':
+		return func(buf *bytes.Buffer, v logEntry) {
+			if v.queryString != "" {
+				buf.WriteByte('?')
+				writeEscaped(buf, v.queryString)
+			}
+		}, nil
+	case 'H':
+		return stringField(func(v logEntry) string { return v.proto }), nil
+	case 'i':
+		if param == "" {
+			return nil, fmt.Errorf("%%i requires a header name")
+		}
+		return stringField(func(v logEntry) string { return requestHeader(v, param) }), nil
+	}
+	return nil, fmt.Errorf("unsupported directive %%%c", directive)
+}
+
+// w3cField returns the field rendering a W3C Extended field, and the request header it references if any.
+func w3cField(name string) (accessLogField, string, error) {
+	if strings.HasPrefix(name, "cs(") && strings.HasSuffix(name, ")") {
+		header := name[3 : len(name)-1]
+		return w3cValue(func(v logEntry) string { return requestHeader(v, header) }), header, nil
+	}
+	switch name {
+	case "date":
+		return w3cValue(func(v logEntry) string { return v.created.UTC().Format("2006-01-02") }), "", nil
+	case "time":
+		return w3cValue(func(v logEntry) string { return v.created.UTC().Format("15:04:05") }), "", nil
+	case "c-ip":
+		return w3cValue(func(v logEntry) string { return v.ip }), "", nil
+	case "s-ip":
+		return w3cValue(func(v logEntry) string { return v.localIp }), "", nil
+	case "cs-method":
+		return w3cValue(func(v logEntry) string { return v.method }), "", nil
+	case "cs-uri-stem":
+		return w3cValue(func(v logEntry) string { return v.path }), "", nil
+	case "cs-uri-query":
+		return w3cValue(func(v logEntry) string { return v.queryString }), "", nil
+	case "cs-uri":
+		return w3cValue(func(v logEntry) string {
+			if v.queryString == "" {
+				return v.path
+			}
+			return v.path + "?" + v.queryString
+		}), "", nil
+	case "cs-version":
+		return w3cValue(func(v logEntry) string { return v.proto }), "", nil
+	case "sc-status":
+		return w3cValue(func(v logEntry) string { return strconv.Itoa(v.statusCode) }), "", nil
+	case "sc-bytes":
+		return w3cValue(func(v logEntry) string { return strconv.Itoa(v.responseBodySize) }), "", nil
+	case "time-taken":
+		return w3cValue(func(v logEntry) string { return strconv.FormatFloat(v.latency.Seconds(), 'f', 3, 64) }), "", nil
+	}
+	return nil, "", fmt.Errorf("unsupported W3C field %q", name)
+}
+
+// literalField renders a constant string.
+func literalField(s string) accessLogField {
+	return func(buf *bytes.Buffer, _ logEntry) {
+		buf.WriteString(s)
+	}
+}
+
+// stringField renders an escaped value, or "-" when empty.
+func stringField(value func(v logEntry) string) accessLogField {
+	return func(buf *bytes.Buffer, v logEntry) {
+		s := value(v)
+		if s == "" {
+			buf.WriteByte('-')
+			return
+		}
+		writeEscaped(buf, s)
+	}
+}
+
+// w3cValue renders an escaped value with spaces replaced by "+", or "-" when empty, as required by the W3C format.
+func w3cValue(value func(v logEntry) string) accessLogField {
+	return stringField(func(v logEntry) string {
+		return strings.ReplaceAll(value(v), " ", "+")
+	})
+}
+
+// writeEscaped writes a request value as Apache does, so a client cannot forge fields or lines: quotes and
+// backslashes are prefixed with a backslash, and control characters are written as \b, \n, \r, \t, \v or \xhh.
+func writeEscaped(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\v':
+			buf.WriteString(`\v`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				buf.WriteString(`\x`)
+				buf.WriteByte(hex[c>>4])
+				buf.WriteByte(hex[c&0xf])
+				continue
+			}
+			buf.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func accessLogEntries() []logEntry {
+	created := time.Date(2025, time.September, 11, 3, 34, 22, 0, time.FixedZone("CEST", 2*60*60))
+	return []logEntry{
+		{
+			created:    created,
+			ip:         "127.0.0.1",
+			ua:         "Mozilla/5.0 (X11; Linux x86_64)",
+			method:     "GET",
+			proto:      "HTTP/1.1",
+			statusCode: 200,
+			realtimeDetails: realtimeDetails{
+				path:             "/index.html",
+				queryString:      "lang=en",
+				localIp:          "10.0.0.5",
+				referer:          "https://example.com/",
+				latency:          1500 * time.Millisecond,
+				responseBodySize: 2326,
+				accessHeaders:    map[string]string{"X-Request-Id": "abc-123"},
+			},
+		},
+		{
+			created:    created.Add(time.Second),
+			ip:         "2001:db8::1",
+			method:     "POST",
+			proto:      "HTTP/2.0",
+			statusCode: 404,
+			realtimeDetails: realtimeDetails{
+				path:    "/missing",
+				latency: 250 * time.Microsecond,
+			},
+		},
+		{
+			created:    created.Add(2 * time.Second),
+			ip:         "203.0.113.7",
+			ua:         "evil\" \"agent\\\n127.0.0.1 - - [forged]",
+			method:     "GET",
+			proto:      "HTTP/1.1",
+			statusCode: 400,
+			realtimeDetails: realtimeDetails{
+				path:          "/search\"x\\y\t",
+				queryString:   "q=a b\x01\x7f",
+				referer:       "https://example.com/\"\r\n",
+				accessHeaders: map[string]string{"X-Request-Id": "id\x1b[31m"},
+			},
+		},
+		{
+			created:     created,
+			isAggregate: true,
+			count:       10,
+		},
+	}
+}
+
+func TestAccessLogWriterGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		writer func(buf *bytes.Buffer) (*AccessLogWriter, error)
+	}{
+		{"Common", "accesslog_common.golden", func(buf *bytes.Buffer) (*AccessLogWriter, error) {
+			return NewAccessLogWriter(buf, CommonLogFormat)
+		}},
+		{"Combined", "accesslog_combined.golden", func(buf *bytes.Buffer) (*AccessLogWriter, error) {
+			return NewAccessLogWriter(buf, CombinedLogFormat)
+		}},
+		{"Custom", "accesslog_custom.golden", func(buf *bytes.Buffer) (*AccessLogWriter, error) {
+			return NewAccessLogWriter(buf, `%a %A %m %U%q %H %s %B %D %T %{X-Request-Id}i 100%%`)
+		}},
+		{"W3C", "accesslog_w3c.golden", func(buf *bytes.Buffer) (*AccessLogWriter, error) {
+			return NewW3CWriter(buf)
+		}},
+		{"W3CCustomFields", "accesslog_w3c_custom.golden", func(buf *bytes.Buffer) (*AccessLogWriter, error) {
+			return NewW3CWriter(buf, "date", "time", "s-ip", "cs-uri", "sc-status", "cs(X-Request-Id)")
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := test.writer(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, e := range accessLogEntries() {
+				w.write(e)
+			}
+
+			golden := filepath.Join("testdata", test.golden)
+			if *updateGolden {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), expected) {
+				t.Errorf("unexpected output:\ngot:\n%s\nwant:\n%s", buf.String(), expected)
+			}
+		})
+	}
+}
+
+func TestAccessLogFormatErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+	}{
+		{"TrailingPercent", "%h %"},
+		{"UnknownDirective", "%h %Z"},
+		{"UnterminatedHeader", "%{Referer"},
+		{"MissingDirective", "%{Referer}"},
+		{"HeaderWithoutName", "%i"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewAccessLogWriter(&bytes.Buffer{}, test.format); err == nil {
+				t.Errorf("expected error for format %q", test.format)
+			}
+		})
+	}
+
+	if _, err := NewW3CWriter(&bytes.Buffer{}, "date", "unknown-field"); err == nil {
+		t.Error("expected error for unknown W3C field")
+	}
+}
+
+func TestAccessLogServerAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	w3c, err := NewW3CWriter(&buf, "c-ip", "s-ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := New(context.Background(), WithDefaultOutput(false), WithAccessLog(w3c))
+	r := gin.New()
+	r.Use(logger.Middleware())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	local := &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 8080}
+	r.ServeHTTP(httptest.NewRecorder(), req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, local)))
+
+	if !strings.HasSuffix(buf.String(), "\n192.0.2.1 10.0.0.5\n") {
+		t.Errorf("expected the client and server addresses, got %q", buf.String())
+	}
+}
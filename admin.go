@@ -0,0 +1,278 @@
+package slogger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// redacted replaces the values that may hold secrets in the admin output.
+const redacted = "[REDACTED]"
+
+// maxAdminBodySize is the maximum size of a POST body accepted by the admin handler.
+const maxAdminBodySize = 64 << 10
+
+// secretKeys are the substrings of static entry keys whose values are redacted in the admin output.
+var secretKeys = []string{"auth", "token", "secret", "password", "passwd", "key", "credential", "cookie", "session"}
+
+// adminStatus is the document served by the admin handler.
+type adminStatus struct {
+	Config adminConfig  `json:"config"`
+	Queues adminQueues  `json:"queues"`
+	Window *adminWindow `json:"window,omitempty"`
+}
+
+type adminConfig struct {
+	Mode                string              `json:"mode"`
+	AggregationInterval string              `json:"aggregationInterval"`
+	RollupIntervals     []string            `json:"rollupIntervals,omitempty"`
+	QueueSize           int                 `json:"queueSize"`
+	LogQueryString      bool                `json:"logQueryString"`
+	LogHeaders          bool                `json:"logHeaders"`
+	LogRequestBody      int                 `json:"logRequestBody,omitempty"`
+	HeaderToLogs        map[string][]string `json:"headerToLogs,omitempty"`
+	IPHeaders           []string            `json:"ipHeaders,omitempty"`
+	UAHeaders           []string            `json:"uaHeaders,omitempty"`
+	StaticLogEntries    map[string]string   `json:"staticLogEntries,omitempty"`
+	SkipPaths           []string            `json:"skipPaths,omitempty"`
+	SkipRules           int                 `json:"skipRules"`
+	SlowThreshold       string              `json:"slowThreshold,omitempty"`
+	SampleRatio         float64             `json:"sampleRatio"`
+	RouteSampleRatios   map[string]float64  `json:"routeSampleRatios,omitempty"`
+	RateLimited         bool                `json:"rateLimited"`
+	Level               string              `json:"level"`
+	ErrorLevel          string              `json:"errorLevel"`
+	Schema              string              `json:"schema"`
+	DefaultOutput       bool                `json:"defaultOutput"`
+	Async               bool                `json:"async"`
+	Sinks               []string            `json:"sinks,omitempty"`
+	BotDetector         bool                `json:"botDetector"`
+	Checkpoint          string              `json:"checkpoint,omitempty"`
+}
+
+type adminQueues struct {
+	AggregationDepth    int    `json:"aggregationDepth"`
+	AggregationCapacity int    `json:"aggregationCapacity"`
+	AsyncDepth          int    `json:"asyncDepth"`
+	AsyncDropped        uint64 `json:"asyncDropped"`
+	SinkDropped         uint64 `json:"sinkDropped"`
+	Skipped             uint64 `json:"skipped"`
+}
+
+type adminWindow struct {
+	Start          time.Time          `json:"start"`
+	Interval       string             `json:"interval"`
+	Requests       int                `json:"requests"`
+	Entries        []adminWindowEntry `json:"entries"`
+	Top            *TopK              `json:"top,omitempty"`
+	OverflowedKeys int                `json:"overflowedKeys,omitempty"`
+}
+
+type adminWindowEntry struct {
+	Method        string `json:"method"`
+	AggregatePath string `json:"aggregatePath"`
+	StatusCode    int    `json:"statusCode"`
+	IP            string `json:"ip"`
+	UserAgent     string `json:"ua"`
+	Count         int    `json:"count"`
+	MeanLatency   string `json:"meanLatency"`
+	MaxLatency    string `json:"maxLatency"`
+	SlowCount     int    `json:"slowCount,omitempty"`
+}
+
+// adminSettings is the body accepted by the admin handler to change the configuration at runtime.
+// Omitted fields are left unchanged.
+type adminSettings struct {
+	Mode        *string  `json:"mode"`
+	SampleRatio *float64 `json:"sampleRatio"`
+	Level       *string  `json:"level"`
+}
+
+// AdminHandler returns an http.Handler to inspect and control the Logger at runtime, eg: mounted on an internal port
+// with http.Handle("/logger/", http.StripPrefix("/logger", logger.AdminHandler())) or with gin.WrapH.
+//
+// GET returns the effective configuration, with secret-looking static entries redacted and sinks listed by type,
+// the queue depths, the dropped counters and the aggregation window in progress, as JSON.
+// POST applies a JSON body of at most 64 KiB, such as {"mode": "aggregate", "sampleRatio": 0.1, "level": "warn"},
+// with Logger.Update.
+// The mode is "realtime", "aggregate" or "both"; the level is debug, info, warn, error or "status" for StatusLevels.
+func (a *Logger) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			writeJSON(w, http.StatusOK, a.adminStatus())
+		case http.MethodPost:
+			var settings adminSettings
+			dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodySize))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&settings); err != nil {
+				status := http.StatusBadRequest
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					status = http.StatusRequestEntityTooLarge
+				}
+				writeJSON(w, status, map[string]string{"error": err.Error()})
+				return
+			}
+			opts, err := settings.options()
+			if err == nil {
+				err = a.Update(opts...)
+			}
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, a.adminStatus())
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	})
+}
+
+// options converts the settings to options.
+func (s adminSettings) options() ([]Option, error) {
+	var opts []Option
+	if s.Mode != nil {
+		switch *s.Mode {
+		case "realtime":
+			opts = append(opts, WithRoutingPolicy(nil), WithAggregation(false))
+		case "aggregate":
+			opts = append(opts, WithRoutingPolicy(nil), WithAggregation(true))
+		case "both":
+			opts = append(opts, WithRoutingPolicy(func(RoutingInfo) Destination { return DestinationBoth }))
+		default:
+			return nil, fmt.Errorf("unknown mode %q, expected realtime, aggregate or both", *s.Mode)
+		}
+	}
+	if s.SampleRatio != nil {
+		opts = append(opts, WithSampleRatio(*s.SampleRatio))
+	}
+	if s.Level != nil {
+		if *s.Level == "status" {
+			opts = append(opts, WithLevelFunc(StatusLevels()))
+		} else {
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(*s.Level)); err != nil {
+				return nil, fmt.Errorf("unknown level %q", *s.Level)
+			}
+			opts = append(opts, WithLevel(level))
+		}
+	}
+	return opts, nil
+}
+
+// adminStatus collects the document served by the admin handler.
+func (a *Logger) adminStatus() adminStatus {
+	c := a.config()
+	status := adminStatus{
+		Config: c.adminConfig(),
+		Queues: adminQueues{
+			AggregationDepth:    len(a.queue),
+			AggregationCapacity: cap(a.queue),
+			AsyncDropped:        a.AsyncDroppedCount(),
+			SinkDropped:         a.SinkDroppedCount(),
+			Skipped:             a.SkippedCount(),
+		},
+	}
+	if p := a.async.Load(); p != nil {
+		status.Queues.AsyncDepth = len(p.queue)
+	}
+	if w, ok := a.currentWindow(); ok {
+		ws := w.stats()
+		aw := &adminWindow{Start: ws.Start, Interval: ws.Interval.String(), Requests: ws.Requests, OverflowedKeys: ws.OverflowedKeys, Entries: make([]adminWindowEntry, 0, len(ws.Buckets))}
+		for _, b := range ws.Buckets {
+			aw.Entries = append(aw.Entries, adminWindowEntry{
+				Method:        b.Method,
+				AggregatePath: b.AggregatePath,
+				StatusCode:    b.StatusCode,
+				IP:            b.IP,
+				UserAgent:     b.UserAgent,
+				Count:         b.Count,
+				MeanLatency:   b.MeanLatency().String(),
+				MaxLatency:    b.MaxLatency.String(),
+				SlowCount:     b.SlowCount,
+			})
+		}
+		if top := ws.Top; len(top.IPs)+len(top.Paths)+len(top.UserAgents)+len(top.ErrorRoutes) > 0 {
+			aw.Top = &top
+		}
+		status.Window = aw
+	}
+	return status
+}
+
+// adminConfig returns the effective configuration with secrets redacted.
+func (c *conf) adminConfig() adminConfig {
+	mode := "realtime"
+	switch {
+	case c.routingPolicy != nil:
+		mode = "policy"
+	case c.isAggregationEnabled:
+		mode = "aggregate"
+	}
+	ac := adminConfig{
+		Mode:                mode,
+		AggregationInterval: c.aggregationInterval.String(),
+		QueueSize:           c.aggregationQueueSize,
+		LogQueryString:      c.logQueryString,
+		LogHeaders:          c.logHeaders,
+		LogRequestBody:      c.requestBodyLimit,
+		HeaderToLogs:        c.logHeadersWithName,
+		IPHeaders:           c.clientIPHeaders,
+		UAHeaders:           c.userAgentHeaders,
+		SkipPaths:           c.excludedPaths,
+		SkipRules:           len(c.skipRules),
+		SampleRatio:         c.globalSampleRatio(),
+		RouteSampleRatios:   c.routeSampleRatios,
+		RateLimited:         c.rateLimiter != nil,
+		Level:               c.level(http.StatusOK, false).String(),
+		ErrorLevel:          c.level(http.StatusInternalServerError, false).String(),
+		Schema:              fmt.Sprintf("%T", c.outputSchema()),
+		DefaultOutput:       !c.disableDefaultOutput,
+		Async:               c.async != nil,
+		BotDetector:         c.botDetectionService != nil,
+		Checkpoint:          c.checkpointPath,
+	}
+	if c.slowThreshold > 0 {
+		ac.SlowThreshold = c.slowThreshold.String()
+	}
+	if len(c.staticLogEntries) > 0 {
+		ac.StaticLogEntries = make(map[string]string, len(c.staticLogEntries))
+		for k, v := range c.staticLogEntries {
+			if isSecretKey(k) {
+				v = redacted
+			}
+			ac.StaticLogEntries[k] = v
+		}
+	}
+	for _, interval := range c.rollupIntervals {
+		ac.RollupIntervals = append(ac.RollupIntervals, interval.String())
+	}
+	for _, w := range c.sinks {
+		ac.Sinks = append(ac.Sinks, fmt.Sprintf("%T", w.sink))
+	}
+	return ac
+}
+
+// isSecretKey reports whether a key looks like it names a secret, eg: apiKey or X-Auth-Token.
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	return slices.ContainsFunc(secretKeys, func(s string) bool {
+		return strings.Contains(key, s)
+	})
+}
+
+// writeJSON writes v as an indented JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,90 @@
+package slogger
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := New(ctx,
+		WithTimeAggregation(time.Hour),
+		WithTopK(1),
+		WithLogger(slog.New(slog.NewTextHandler(&lockedBuffer{}, nil))),
+		WithStaticLogEntries(map[string]string{"service": "shop", "apiKey": "s3cr3t"}),
+		WithSink(SinkFunc(func(Entry) error { return nil })),
+	)
+	r := gin.New()
+	r.Use(logger.Middleware())
+	r.GET("/api/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.Any("/admin", gin.WrapH(logger.AdminHandler()))
+	for i := 0; i < 3; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+		check          func(t *testing.T, status adminStatus)
+	}{
+		{"Status", http.MethodGet, "", http.StatusOK, func(t *testing.T, status adminStatus) {
+			if status.Config.Mode != "aggregate" || status.Config.StaticLogEntries["apiKey"] != redacted || status.Config.StaticLogEntries["service"] != "shop" {
+				t.Errorf("unexpected config %+v", status.Config)
+			}
+			if len(status.Config.Sinks) != 1 || status.Queues.AggregationCapacity != 100 {
+				t.Errorf("unexpected sinks or queues %v %+v", status.Config.Sinks, status.Queues)
+			}
+			if status.Window == nil || status.Window.Requests != 3 || status.Window.Entries[0].AggregatePath != "/api/users" {
+				t.Errorf("expected the window in progress, got %+v", status.Window)
+			} else if top := status.Window.Top; top == nil || len(top.Paths) != 1 || top.Paths[0] != (HeavyHitter{Key: "/api/users", Count: 3}) {
+				t.Errorf("expected the heavy hitters of the window, got %+v", top)
+			}
+		}},
+		{"Update", http.MethodPost, `{"mode": "realtime", "sampleRatio": 0.25, "level": "warn"}`, http.StatusOK, func(t *testing.T, status adminStatus) {
+			if status.Config.Mode != "realtime" || status.Config.SampleRatio != 0.25 || status.Config.Level != "WARN" {
+				t.Errorf("expected the update to be applied, got %+v", status.Config)
+			}
+		}},
+		{"StatusLevels", http.MethodPost, `{"level": "status"}`, http.StatusOK, func(t *testing.T, status adminStatus) {
+			if status.Config.Level != "INFO" || status.Config.ErrorLevel != "ERROR" {
+				t.Errorf("expected status levels, got %s and %s", status.Config.Level, status.Config.ErrorLevel)
+			}
+		}},
+		{"InvalidSampleRatio", http.MethodPost, `{"sampleRatio": 2}`, http.StatusBadRequest, nil},
+		{"UnknownMode", http.MethodPost, `{"mode": "verbose"}`, http.StatusBadRequest, nil},
+		{"UnknownField", http.MethodPost, `{"logHeaders": true}`, http.StatusBadRequest, nil},
+		{"BodyTooLarge", http.MethodPost, `{"mode": "` + strings.Repeat("x", maxAdminBodySize) + `"}`, http.StatusRequestEntityTooLarge, nil},
+		{"MethodNotAllowed", http.MethodDelete, "", http.StatusMethodNotAllowed, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(test.method, "/admin", strings.NewReader(test.body)))
+			if w.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", test.expectedStatus, w.Code, w.Body.String())
+			}
+			if test.check == nil {
+				return
+			}
+			var status adminStatus
+			if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+				t.Fatal(err)
+			}
+			test.check(t, status)
+		})
+	}
+}
@@ -2,78 +2,236 @@ package slogger
 
 import (
 	"context"
+	"log/slog"
+	"maps"
+	"slices"
 	"strconv"
 	"time"
 )
 
+// window is a copy of an aggregation window. The end is zero while the window is in progress.
+type window struct {
+	start    time.Time
+	end      time.Time
+	interval time.Duration
+	entries  []logEntry
+	top      TopK
+	routes   []RouteStats
+	// overflowedKeys is the estimated number of distinct keys folded into overflow buckets.
+	overflowedKeys int
+	// history holds the last completed windows, most recent first, in the copy of the window in progress.
+	history []window
+}
+
+// currentWindow asks the aggregator for a copy of the window in progress and of the completed windows kept for
+// snapshots. It returns false when the aggregator is not running.
+func (a *Logger) currentWindow() (window, bool) {
+	if !a.aggregatorRunning.Load() {
+		return window{}, false
+	}
+	reply := make(chan window, 1)
+	select {
+	case a.inspect <- reply:
+	case <-a.ctx.Done():
+		return window{}, false
+	}
+	select {
+	case w := <-reply:
+		return w, true
+	case <-a.ctx.Done():
+		return window{}, false
+	}
+}
+
+// windowState is the aggregation window in progress.
+type windowState struct {
+	start   time.Time
+	entries map[string]logEntry
+	top     *heavyHitters
+	unique  *uniqueClients
+	// keys is the number of buckets, overflow buckets excluded, and maxKeys its limit, zero when unlimited.
+	keys       int
+	maxKeys    int
+	overflowed *linearCounter
+	// rollup is true for the windows of the rollup intervals, which sinks receive only when asked with SinkResolutions.
+	rollup bool
+}
+
+// newWindowState starts a window with the current configuration.
+func (a *Logger) newWindowState(start time.Time) *windowState {
+	logConf := a.config()
+	w := &windowState{start: start, entries: make(map[string]logEntry), top: newHeavyHitters(logConf.topK), unique: newUniqueClients(logConf.uniqueClientsPrecision, logConf.maxAggregationKeys), maxKeys: logConf.maxAggregationKeys}
+	if w.maxKeys > 0 {
+		w.overflowed = newLinearCounter()
+	}
+	return w
+}
+
+// snapshot returns a copy of the window.
+func (w *windowState) snapshot(end time.Time, interval time.Duration) window {
+	return window{start: w.start, end: end, interval: interval, entries: slices.Collect(maps.Values(w.entries)), top: w.top.top(), routes: w.unique.stats(), overflowedKeys: w.overflowed.estimate()}
+}
+
 // initLoggerAggregator initializes the logging aggregator, periodically processing and emitting aggregated log statistics.
 func (a *Logger) initLoggerAggregator(ctx context.Context) {
 	c := a.queue
-	duration := a.conf.aggregationInterval
+	duration := a.config().aggregationInterval
 	t := time.NewTicker(duration)
-	logEntries := make(map[string]logEntry)
+	w := a.newWindowState(time.Now())
+	rollups := a.updateRollups(nil, w.start)
+	// period is the current period of the ticker, shorter than duration until the end of a restored window.
+	period := duration
+	if a.restoreCheckpoint(w, duration, rollups) {
+		period = max(time.Until(w.start.Add(duration)), time.Nanosecond)
+		t.Reset(period)
+	}
+	var checkpoints <-chan time.Time
+	var ct *time.Ticker
+	if every := a.config().checkpointInterval; a.config().checkpointPath != "" && every > 0 {
+		ct = time.NewTicker(every)
+		checkpoints = ct.C
+	}
+	var history []window
+	a.aggregatorRunning.Store(true)
 	go func() {
+		defer close(a.aggregatorDone)
+		defer a.aggregatorRunning.Store(false)
 		for {
 			select {
 			case <-ctx.Done():
 				t.Stop()
-				a.printLogs(logEntries, duration)
-				logEntries = make(map[string]logEntry)
+				if ct != nil {
+					ct.Stop()
+				}
+				// The checkpoint is kept until the sinks confirm the delivery of the last windows.
+				a.saveCheckpoint(w, duration, rollups)
+				a.printWindow(w, duration)
+				a.rollUp(rollups, w, duration, time.Now(), true)
+				a.removeCheckpoint()
+				return
 
-			case <-t.C:
-				a.printLogs(logEntries, duration)
+			case <-checkpoints:
+				a.saveCheckpoint(w, duration, rollups)
 
-				logEntries = make(map[string]logEntry)
-			case st := <-c:
+			case <-t.C:
+				a.printWindow(w, duration)
 
-				//Update stats
-				var v logEntry
-				var ok bool
-
-				key := st.ip + "_" + strconv.Itoa(st.statusCode) + "_" +
-					st.ua + "_" + st.method + "_" + st.proto + "_" + "_" + st.aggregatePath
-				if v, ok = logEntries[key]; !ok {
-					hasBotDetector, isBot := a.conf.botDetectorInfo(st.ua)
-					v = logEntry{
-						created:     time.Now().UTC(),
-						ip:          st.ip,
-						remoteIp:    st.remoteIp,
-						ua:          st.ua,
-						method:      st.method,
-						statusCode:  st.statusCode,
-						isAggregate: true,
-
-						aggregateDetails: aggregateDetails{
-							lastMod:          time.Now().UTC(),
-							sumLatency:       0,
-							maxLatency:       st.latency,
-							minLatency:       st.latency,
-							sumSizeRespoBody: st.responseBodySize,
-						},
-
-						isBotDetectorEnabled: hasBotDetector,
-						isBot:                isBot,
-						proto:                st.proto,
-						aggregatePath:        st.aggregatePath,
-					}
+				now := time.Now()
+				a.rollUp(rollups, w, duration, now, false)
+				rollups = a.updateRollups(rollups, now)
+				if keep := a.config().snapshotHistory; keep > 0 {
+					history = append([]window{w.snapshot(now, duration)}, history[:min(len(history), keep-1)]...)
+				} else {
+					history = nil
 				}
-				v.count++
-				if v.maxLatency < st.latency {
-					v.maxLatency = st.latency
+				w = a.newWindowState(now)
+				if interval := a.config().aggregationInterval; interval != duration && interval > 0 {
+					duration = interval
 				}
-				if v.minLatency > st.latency {
-					v.minLatency = st.latency
+				if period != duration {
+					period = duration
+					t.Reset(duration)
 				}
-
-				v.sumLatency += st.latency
-				v.sumSizeRespoBody += st.responseBodySize
-				logEntries[key] = v
-
+				a.saveCheckpoint(w, duration, rollups)
+			case reply := <-a.inspect:
+				// Entries already accepted by the queue are part of the window.
+				for drained := false; !drained; {
+					select {
+					case st := <-c:
+						a.aggregate(w, st)
+					default:
+						drained = true
+					}
+				}
+				current := w.snapshot(time.Time{}, duration)
+				current.history = slices.Clone(history)
+				reply <- current
+			case st := <-c:
+				a.aggregate(w, st)
 			}
 		}
 	}()
 }
 
+// aggregate adds a request entry to the bucket of its key in the window. Once the window holds maxKeys buckets,
+// requests with a new key are folded into the overflow bucket of their method, route and status code.
+func (a *Logger) aggregate(w *windowState, st logEntry) {
+	w.top.add(st)
+	w.unique.add(st)
+
+	//Update stats
+	var v logEntry
+	var ok bool
+
+	key := aggregationKey(st)
+	overflow := false
+	if v, ok = w.entries[key]; !ok && w.maxKeys > 0 && w.keys >= w.maxKeys {
+		w.overflowed.add(key)
+		overflow = true
+		st.ip, st.remoteIp, st.ua, st.proto = overflowKey, "", overflowKey, ""
+		key = aggregationKey(st)
+		v, ok = w.entries[key]
+	}
+	if !ok {
+		hasBotDetector, isBot := false, 0
+		if !overflow {
+			hasBotDetector, isBot = a.config().botDetectorInfo(st.ua)
+			w.keys++
+		}
+		v = logEntry{
+			created:     time.Now().UTC(),
+			ip:          st.ip,
+			remoteIp:    st.remoteIp,
+			ua:          st.ua,
+			method:      st.method,
+			statusCode:  st.statusCode,
+			level:       st.level,
+			isAggregate: true,
+
+			aggregateDetails: aggregateDetails{
+				lastMod:          time.Now().UTC(),
+				sumLatency:       0,
+				maxLatency:       st.latency,
+				minLatency:       st.latency,
+				sumSizeRespoBody: st.responseBodySize,
+			},
+
+			isBotDetectorEnabled: hasBotDetector,
+			isBot:                isBot,
+			proto:                st.proto,
+			aggregatePath:        st.aggregatePath,
+		}
+	}
+	v.count++
+	v.level = max(v.level, st.level)
+	if v.maxLatency < st.latency {
+		v.maxLatency = st.latency
+	}
+	if v.minLatency > st.latency {
+		v.minLatency = st.latency
+	}
+
+	if st.isSlow {
+		v.slowCount++
+	}
+
+	v.sumLatency += st.latency
+	v.sumSizeRespoBody += st.responseBodySize
+	v.durationCounts[bucketIndex(aggregateDurationBounds[:], st.latency.Seconds())]++
+	v.sizeCounts[bucketIndex(aggregateSizeBounds[:], float64(st.responseBodySize))]++
+	w.entries[key] = v
+}
+
+// aggregationKey returns the key of the bucket of an entry: its IP, status code, user agent, method, protocol and
+// aggregate path, or its status code, method and aggregate path for overflow buckets.
+func aggregationKey(st logEntry) string {
+	if st.ip == overflowKey {
+		return overflowKey + "_" + strconv.Itoa(st.statusCode) + "_" + st.method + "_" + st.aggregatePath
+	}
+	return st.ip + "_" + strconv.Itoa(st.statusCode) + "_" +
+		st.ua + "_" + st.method + "_" + st.proto + "_" + "_" + st.aggregatePath
+}
+
 // botDetectorInfo determines if a bot detector instance is enabled and checks if the provided user agent represents a bot.
 func (c *conf) botDetectorInfo(userAgent string) (hasBotDetector bool, isBot int) {
 	hasBotDetector = false
@@ -89,14 +247,51 @@ func (c *conf) botDetectorInfo(userAgent string) (hasBotDetector bool, isBot int
 	return hasBotDetector, isBot
 }
 
-// printLogs processes and prints aggregated log entries for a specified duration, utilizing the provided statistics map.
-func (a *Logger) printLogs(stats map[string]logEntry, duration time.Duration) {
-	if len(stats) == 0 {
+// printLogs processes and prints the aggregated log entries of a window of the specified duration.
+// Each bucket is logged at the highest level of its requests, as chosen by the configuration of their route group.
+func (a *Logger) printLogs(w *windowState, duration time.Duration) {
+	if len(w.entries) == 0 {
 		return
 	}
-	for _, v := range stats {
+	logConf := a.config()
+	for _, v := range w.entries {
+		v.windowStart = w.start
+		v.resolution = duration
+		v.rollup = w.rollup
+		printLog("api_logger v1", v, logConf)
 
-		printLog("api_logger v1", v, a.conf)
+	}
+}
 
+// printSummary emits a summary line of a window, eg: its heavy hitters, through the hooks, schema and sinks like the
+// buckets of the window.
+func (a *Logger) printSummary(msg string, level slog.Level, w *windowState, duration time.Duration, attrs ...slog.Attr) {
+	printLog(msg, logEntry{
+		created:          w.start,
+		level:            level,
+		isAggregate:      true,
+		aggregateDetails: aggregateDetails{windowStart: w.start, resolution: duration, rollup: w.rollup},
+		summary:          attrs,
+	}, a.config())
+}
+
+// printWindow prints the buckets of a window, followed by its heavy hitters, distinct clients and overflow summaries
+// when enabled, then asks the sinks to flush.
+func (a *Logger) printWindow(w *windowState, duration time.Duration) {
+	defer a.config().flushSinks()
+	a.printLogs(w, duration)
+	a.printUniqueClients(w, duration)
+	if w.top == nil && w.overflowed.estimate() == 0 {
+		return
+	}
+	requests := 0
+	for _, v := range w.entries {
+		requests += v.count
+	}
+	if w.top != nil {
+		a.printTopK(w, duration, requests)
+	}
+	if overflowed := w.overflowed.estimate(); overflowed > 0 {
+		a.printOverflow(w, duration, overflowed)
 	}
 }
@@ -0,0 +1,293 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what happens to a realtime entry when the async buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop drops the entry and counts it, so that requests are never delayed by the log output.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock waits for room in the buffer, so that no entry is lost while the Logger is running.
+	OverflowBlock
+)
+
+// asyncConf holds the settings of the async realtime pipeline.
+type asyncConf struct {
+	bufferSize int
+	workers    int
+	batchSize  int
+	policy     OverflowPolicy
+}
+
+// AsyncOption configures the async realtime pipeline enabled with WithAsync.
+type AsyncOption func(c *asyncConf)
+
+// AsyncBufferSize sets the number of realtime entries buffered between the requests and the writers. The default is 4096.
+func AsyncBufferSize(size int) AsyncOption {
+	return func(c *asyncConf) {
+		c.bufferSize = size
+	}
+}
+
+// AsyncWorkers sets the number of writer goroutines. The default is 1; with more writers, entries may be written out of order.
+func AsyncWorkers(n int) AsyncOption {
+	return func(c *asyncConf) {
+		c.workers = n
+	}
+}
+
+// AsyncBatchSize sets the maximum number of entries a writer takes from the buffer at once. The default is 64.
+func AsyncBatchSize(size int) AsyncOption {
+	return func(c *asyncConf) {
+		c.batchSize = size
+	}
+}
+
+// AsyncOverflowPolicy sets what happens when the buffer is full. The default is OverflowDrop.
+func AsyncOverflowPolicy(policy OverflowPolicy) AsyncOption {
+	return func(c *asyncConf) {
+		c.policy = policy
+	}
+}
+
+// WithAsync emits realtime entries from writer goroutines instead of the request goroutine, so that a slow output does
+// not add latency to responses. Use Logger.Flush on shutdown to write the buffered entries.
+func WithAsync(opts ...AsyncOption) Option {
+	return func(c *conf) {
+		ac := &asyncConf{bufferSize: 4096, workers: 1, batchSize: 64, policy: OverflowDrop}
+		for _, opt := range opts {
+			opt(ac)
+		}
+		c.async = ac
+	}
+}
+
+// asyncItem is a realtime entry waiting to be written with the configuration of its route group, or the barrier of a
+// Flush call.
+type asyncItem struct {
+	entry   logEntry
+	conf    *conf
+	barrier *asyncBarrier
+}
+
+// asyncBarrier is queued by Flush once per writer. A writer taking it has written the entries it took before, and
+// waits there for the other writers, so that each writer takes exactly one barrier. Once every writer has reached it,
+// the entries queued before the Flush call are written.
+type asyncBarrier struct {
+	remaining atomic.Int64
+	// done is closed once every writer has reached the barrier, and abort when Flush gives up waiting.
+	done  chan struct{}
+	abort chan struct{}
+}
+
+// arrive marks the barrier as reached by a writer and waits for the other writers, until Flush gives up or ctx is done.
+func (b *asyncBarrier) arrive(ctx context.Context) {
+	if b.remaining.Add(-1) == 0 {
+		close(b.done)
+	}
+	select {
+	case <-b.done:
+	case <-b.abort:
+	case <-ctx.Done():
+	}
+}
+
+// asyncPipeline buffers realtime entries and writes them from worker goroutines.
+type asyncPipeline struct {
+	ctx   context.Context
+	conf  *asyncConf
+	queue chan asyncItem
+	// closeMu makes enqueue and close mutually exclusive, so no entry is queued once the workers drain the buffer.
+	closeMu sync.RWMutex
+	closed  bool
+	dropped atomic.Uint64
+	// workers is done once every writer has returned.
+	workers sync.WaitGroup
+}
+
+// startAsync creates the buffer and starts the writers, which stop once ctx is done and the buffer is drained.
+func startAsync(ctx context.Context, c *asyncConf) *asyncPipeline {
+	p := &asyncPipeline{ctx: ctx, conf: c, queue: make(chan asyncItem, max(c.bufferSize, 1))}
+	for i := 0; i < max(c.workers, 1); i++ {
+		p.workers.Add(1)
+		go func() {
+			defer p.workers.Done()
+			p.run()
+		}()
+	}
+	return p
+}
+
+// wait waits until the writers have returned, once ctx is done and the buffer is drained.
+func (p *asyncPipeline) wait() {
+	p.workers.Wait()
+}
+
+// enqueue buffers an entry according to the overflow policy. After ctx is done, entries are dropped instead of blocking.
+func (p *asyncPipeline) enqueue(v logEntry, c *conf) {
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+	if p.closed {
+		p.dropped.Add(1)
+		return
+	}
+	item := asyncItem{entry: v, conf: c}
+	if p.conf.policy == OverflowBlock {
+		select {
+		case p.queue <- item:
+			return
+		case <-p.ctx.Done():
+		}
+	} else {
+		select {
+		case p.queue <- item:
+			return
+		case <-p.ctx.Done():
+		default:
+		}
+	}
+	p.dropped.Add(1)
+}
+
+// close stops enqueue from queueing entries, waiting for the calls in progress to return.
+func (p *asyncPipeline) close() {
+	p.closeMu.Lock()
+	p.closed = true
+	p.closeMu.Unlock()
+}
+
+// run writes the buffered entries in batches until ctx is done, then writes what is left in the buffer. A batch ends
+// at the first barrier.
+func (p *asyncPipeline) run() {
+	batch := make([]asyncItem, 0, max(p.conf.batchSize, 1))
+	for {
+		select {
+		case item := <-p.queue:
+			batch = append(batch[:0], item)
+		fill:
+			for len(batch) < cap(batch) && batch[len(batch)-1].barrier == nil {
+				select {
+				case item := <-p.queue:
+					batch = append(batch, item)
+				default:
+					break fill
+				}
+			}
+			p.write(batch)
+		case <-p.ctx.Done():
+			p.close()
+			for {
+				select {
+				case item := <-p.queue:
+					p.write([]asyncItem{item})
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// write emits a batch of entries, then reaches the barrier ending the batch, if any.
+func (p *asyncPipeline) write(batch []asyncItem) {
+	for _, item := range batch {
+		if item.barrier != nil {
+			item.barrier.arrive(p.ctx)
+			continue
+		}
+		printLog("api_logger v1", item.entry, item.conf)
+	}
+}
+
+// flush waits until the entries buffered before the call are written or ctx is done. Entries buffered meanwhile, eg:
+// under steady traffic, are not waited for. Once the writers are stopping, it waits until they have drained the buffer.
+func (p *asyncPipeline) flush(ctx context.Context) error {
+	b := &asyncBarrier{done: make(chan struct{}), abort: make(chan struct{})}
+	workers := max(p.conf.workers, 1)
+	b.remaining.Store(int64(workers))
+
+	p.closeMu.RLock()
+	if p.closed {
+		p.closeMu.RUnlock()
+		return p.waitStopped(ctx)
+	}
+	for i := 0; i < workers; i++ {
+		select {
+		case p.queue <- asyncItem{barrier: b}:
+		case <-p.ctx.Done():
+			p.closeMu.RUnlock()
+			close(b.abort)
+			return p.waitStopped(ctx)
+		case <-ctx.Done():
+			p.closeMu.RUnlock()
+			close(b.abort)
+			return ctx.Err()
+		}
+	}
+	p.closeMu.RUnlock()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-p.ctx.Done():
+		return p.waitStopped(ctx)
+	case <-ctx.Done():
+		close(b.abort)
+		return ctx.Err()
+	}
+}
+
+// waitStopped waits until the writers have drained the buffer and returned, or ctx is done.
+func (p *asyncPipeline) waitStopped(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		p.wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// emit writes a realtime entry, through the async pipeline when enabled.
+func (a *Logger) emit(v logEntry, c *conf) {
+	p := a.async.Load()
+	if c.async == nil || p == nil {
+		printLog("api_logger v1", v, c)
+		return
+	}
+	p.enqueue(v, c)
+}
+
+// Flush waits until the realtime entries buffered by WithAsync before the call are written, then until every sink has
+// emitted the entries queued so far and flushed. It returns ctx.Err() if ctx is done first, eg: when a shutdown deadline expires.
+func (a *Logger) Flush(ctx context.Context) error {
+	if p := a.async.Load(); p != nil {
+		if err := p.flush(ctx); err != nil {
+			return err
+		}
+	}
+	if err := a.config().flushSinksAndWait(ctx); !errors.Is(err, errSinkStopped) {
+		return err
+	}
+	// The Logger has stopped: the sinks delivered every entry queued before, and SinkDroppedCount counts the others.
+	return nil
+}
+
+// AsyncDroppedCount returns the number of realtime entries dropped because the async buffer was full.
+func (a *Logger) AsyncDroppedCount() uint64 {
+	p := a.async.Load()
+	if p == nil {
+		return 0
+	}
+	return p.dropped.Load()
+}
@@ -0,0 +1,156 @@
+package slogger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAsyncPipeline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		opts            []AsyncOption
+		expectedDropped bool
+	}{
+		{"Drop", []AsyncOption{AsyncBufferSize(2), AsyncBatchSize(4)}, true},
+		{"Block", []AsyncOption{AsyncBufferSize(2), AsyncOverflowPolicy(OverflowBlock)}, false},
+		{"MultipleWorkers", []AsyncOption{AsyncBufferSize(64), AsyncWorkers(4)}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			release := make(chan struct{})
+			var written atomic.Int64
+			logger := New(ctx, WithAsync(test.opts...), WithDefaultOutput(false), WithOnEntry(func(e *Entry) {
+				<-release
+				written.Add(1)
+			}))
+
+			r := gin.New()
+			r.Use(logger.Middleware())
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			served := make(chan struct{})
+			go func() {
+				defer close(served)
+				for i := 0; i < 10; i++ {
+					r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+				}
+			}()
+			if test.expectedDropped {
+				select {
+				case <-served:
+				case <-time.After(2 * time.Second):
+					t.Fatal("requests were blocked by the log output")
+				}
+			}
+			close(release)
+			<-served
+
+			flushCtx, flushCancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer flushCancel()
+			if err := logger.Flush(flushCtx); err != nil {
+				t.Fatal(err)
+			}
+			dropped := logger.AsyncDroppedCount()
+			if written.Load()+int64(dropped) != 10 {
+				t.Errorf("expected 10 written or dropped entries, got %d written and %d dropped", written.Load(), dropped)
+			}
+			if (dropped > 0) != test.expectedDropped {
+				t.Errorf("unexpected dropped count %d", dropped)
+			}
+		})
+	}
+}
+
+func TestAsyncFlushDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	logger := New(context.Background(), WithAsync(), WithDefaultOutput(false), WithOnEntry(func(e *Entry) {
+		<-release
+	}))
+	logger.emit(logEntry{statusCode: http.StatusOK}, logger.config())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := logger.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestAsyncEnqueueAfterCancel(t *testing.T) {
+	for _, policy := range []OverflowPolicy{OverflowDrop, OverflowBlock} {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := startAsync(ctx, &asyncConf{bufferSize: 64, workers: 2, batchSize: 4, policy: policy})
+		c := configure(WithDefaultOutput(false))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 1000; i++ {
+				p.enqueue(logEntry{statusCode: http.StatusOK}, c)
+			}
+		}()
+		cancel()
+		<-done
+
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if err := p.flush(flushCtx); err != nil {
+			t.Errorf("policy %d: expected entries queued around cancellation to be written or dropped, got %v", policy, err)
+		}
+		flushCancel()
+	}
+}
+
+func TestAsyncFlushUnderSteadyTraffic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var written atomic.Int64
+	logger := New(ctx, WithAsync(AsyncBufferSize(64), AsyncWorkers(2), AsyncBatchSize(4), AsyncOverflowPolicy(OverflowBlock)), WithDefaultOutput(false),
+		WithOnEntry(func(e *Entry) {
+			time.Sleep(100 * time.Microsecond)
+			written.Add(1)
+		}))
+
+	var queued atomic.Int64
+	stop := make(chan struct{})
+	traffic := make(chan struct{})
+	go func() {
+		defer close(traffic)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				logger.emit(logEntry{statusCode: http.StatusOK}, logger.config())
+				queued.Add(1)
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-traffic
+	}()
+	waitFor(t, func() bool { return queued.Load() > 100 })
+
+	// New entries keep the buffer busy: Flush only waits for the entries queued before it was called.
+	before := queued.Load()
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer flushCancel()
+	if err := logger.Flush(flushCtx); err != nil {
+		t.Fatalf("expected Flush to return under steady traffic, got %v", err)
+	}
+	if got := written.Load(); got < before {
+		t.Errorf("expected the %d entries queued before Flush to be written, got %d", before, got)
+	}
+}
@@ -0,0 +1,350 @@
+package slogger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// checkpointVersion is the version of the checkpoint file format.
+const checkpointVersion = 1
+
+// checkpointDeliveryTimeout bounds the wait for the sinks on shutdown before the checkpoint file is removed.
+var checkpointDeliveryTimeout = 10 * time.Second
+
+// WithCheckpoint saves the aggregation windows in progress, rollups included, to a JSON file at path every interval
+// and at the end of each window, so a crash or a rolling deploy does not lose them. When the aggregator starts, the
+// windows of the file that are still open are restored, and the windows that ended meanwhile are emitted with
+// recovered=true. When the context is cancelled, the file is removed once the windows are emitted and every sink has
+// confirmed their delivery; otherwise it is kept, so the windows are recovered by the next start.
+func WithCheckpoint(path string, every time.Duration) Option {
+	return func(c *conf) {
+		c.checkpointPath = path
+		c.checkpointInterval = every
+	}
+}
+
+// Recovered reports whether an aggregated entry belongs to a window restored from a checkpoint after it had ended.
+func (e Entry) Recovered() bool { return e.e.recovered }
+
+// checkpoint is the content of a checkpoint file.
+type checkpoint struct {
+	Version int                `json:"version"`
+	Saved   time.Time          `json:"saved"`
+	Windows []checkpointWindow `json:"windows"`
+}
+
+// checkpointWindow is a window in progress. Elapsed is the duration of the windows merged into a rollup so far.
+type checkpointWindow struct {
+	Start      time.Time          `json:"start"`
+	Interval   time.Duration      `json:"interval"`
+	Rollup     bool               `json:"rollup,omitempty"`
+	Elapsed    time.Duration      `json:"elapsed,omitempty"`
+	Buckets    []checkpointBucket `json:"buckets"`
+	Top        *checkpointTop     `json:"top,omitempty"`
+	Routes     []checkpointRoute  `json:"routes,omitempty"`
+	Overflowed []uint64           `json:"overflowed,omitempty"`
+}
+
+type checkpointBucket struct {
+	Created         time.Time     `json:"created"`
+	LastMod         time.Time     `json:"lastMod"`
+	IP              string        `json:"ip"`
+	RemoteIP        string        `json:"remoteIp,omitempty"`
+	UserAgent       string        `json:"ua"`
+	Method          string        `json:"method"`
+	Proto           string        `json:"proto"`
+	AggregatePath   string        `json:"aggregatePath"`
+	StatusCode      int           `json:"statusCode"`
+	Level           slog.Level    `json:"level"`
+	Count           int           `json:"count"`
+	SumLatency      time.Duration `json:"sumLatency"`
+	MinLatency      time.Duration `json:"minLatency"`
+	MaxLatency      time.Duration `json:"maxLatency"`
+	SumResponseSize int           `json:"sumResponseSize"`
+	SlowCount       int           `json:"slowCount,omitempty"`
+	BotDetected     bool          `json:"botDetected,omitempty"`
+	IsBot           int           `json:"isBot,omitempty"`
+	DurationCounts  []int         `json:"durationCounts,omitempty"`
+	SizeCounts      []int         `json:"sizeCounts,omitempty"`
+}
+
+// checkpointTop holds every counter of the heavy hitters sketches, not only the top k.
+type checkpointTop struct {
+	IPs         []HeavyHitter `json:"ips"`
+	Paths       []HeavyHitter `json:"paths"`
+	UserAgents  []HeavyHitter `json:"userAgents"`
+	ErrorRoutes []HeavyHitter `json:"errorRoutes"`
+}
+
+// checkpointRoute holds the sketches of a route, encoded with HyperLogLog.MarshalBinary.
+type checkpointRoute struct {
+	Method        string `json:"method"`
+	AggregatePath string `json:"aggregatePath"`
+	Requests      int    `json:"requests"`
+	Clients       []byte `json:"clients"`
+	ClientAgents  []byte `json:"clientAgents"`
+}
+
+// checkpoint returns the checkpoint form of the window.
+func (w *windowState) checkpoint(interval time.Duration, rollup bool, elapsed time.Duration) checkpointWindow {
+	cw := checkpointWindow{Start: w.start, Interval: interval, Rollup: rollup, Elapsed: elapsed, Buckets: make([]checkpointBucket, 0, len(w.entries))}
+	for _, v := range w.entries {
+		cw.Buckets = append(cw.Buckets, checkpointBucket{
+			Created:         v.created,
+			LastMod:         v.lastMod,
+			IP:              v.ip,
+			RemoteIP:        v.remoteIp,
+			UserAgent:       v.ua,
+			Method:          v.method,
+			Proto:           v.proto,
+			AggregatePath:   v.aggregatePath,
+			StatusCode:      v.statusCode,
+			Level:           v.level,
+			Count:           v.count,
+			SumLatency:      v.sumLatency,
+			MinLatency:      v.minLatency,
+			MaxLatency:      v.maxLatency,
+			SumResponseSize: v.sumSizeRespoBody,
+			SlowCount:       v.slowCount,
+			BotDetected:     v.isBotDetectorEnabled,
+			IsBot:           v.isBot,
+			DurationCounts:  v.durationCounts[:],
+			SizeCounts:      v.sizeCounts[:],
+		})
+	}
+	if w.top != nil {
+		cw.Top = &checkpointTop{
+			IPs:         w.top.ips.top(w.top.ips.capacity),
+			Paths:       w.top.paths.top(w.top.paths.capacity),
+			UserAgents:  w.top.userAgents.top(w.top.userAgents.capacity),
+			ErrorRoutes: w.top.errorRoutes.top(w.top.errorRoutes.capacity),
+		}
+	}
+	if w.unique != nil {
+		for _, r := range w.unique.routes {
+			clients, _ := r.clients.MarshalBinary()
+			clientAgents, _ := r.clientAgents.MarshalBinary()
+			cw.Routes = append(cw.Routes, checkpointRoute{Method: r.method, AggregatePath: r.aggregatePath, Requests: r.requests, Clients: clients, ClientAgents: clientAgents})
+		}
+	}
+	if w.overflowed != nil {
+		cw.Overflowed = w.overflowed.bitmap[:]
+	}
+	return cw
+}
+
+// restoreWindow rebuilds a window from its checkpoint form with the current configuration. Sketches that are
+// disabled or whose precision changed are dropped.
+func (a *Logger) restoreWindow(cw checkpointWindow) *windowState {
+	w := a.newWindowState(cw.Start)
+	w.rollup = cw.Rollup
+	for _, b := range cw.Buckets {
+		v := logEntry{
+			created:              b.Created,
+			ip:                   b.IP,
+			remoteIp:             b.RemoteIP,
+			ua:                   b.UserAgent,
+			method:               b.Method,
+			proto:                b.Proto,
+			aggregatePath:        b.AggregatePath,
+			statusCode:           b.StatusCode,
+			level:                b.Level,
+			count:                b.Count,
+			isAggregate:          true,
+			isBotDetectorEnabled: b.BotDetected,
+			isBot:                b.IsBot,
+			aggregateDetails: aggregateDetails{
+				lastMod:          b.LastMod,
+				sumLatency:       b.SumLatency,
+				minLatency:       b.MinLatency,
+				maxLatency:       b.MaxLatency,
+				sumSizeRespoBody: b.SumResponseSize,
+				slowCount:        b.SlowCount,
+			},
+		}
+		copy(v.durationCounts[:], b.DurationCounts)
+		copy(v.sizeCounts[:], b.SizeCounts)
+		w.entries[aggregationKey(v)] = v
+		if v.ip != overflowKey {
+			w.keys++
+		}
+	}
+	if w.top != nil && cw.Top != nil {
+		restore := func(s *spaceSaving, hitters []HeavyHitter) {
+			for _, h := range hitters {
+				s.addCount(h.Key, h.Count, h.Error)
+			}
+		}
+		restore(w.top.ips, cw.Top.IPs)
+		restore(w.top.paths, cw.Top.Paths)
+		restore(w.top.userAgents, cw.Top.UserAgents)
+		restore(w.top.errorRoutes, cw.Top.ErrorRoutes)
+	}
+	if w.unique != nil {
+		for _, r := range cw.Routes {
+			var clients, clientAgents HyperLogLog
+			if clients.UnmarshalBinary(r.Clients) != nil || clientAgents.UnmarshalBinary(r.ClientAgents) != nil ||
+				clients.Precision() != w.unique.precision || clientAgents.Precision() != w.unique.precision {
+				continue
+			}
+			w.unique.routes[r.Method+" "+r.AggregatePath] = &routeClients{method: r.Method, aggregatePath: r.AggregatePath, requests: r.Requests, clients: &clients, clientAgents: &clientAgents}
+		}
+	}
+	if w.overflowed != nil && len(cw.Overflowed) == len(w.overflowed.bitmap) {
+		copy(w.overflowed.bitmap[:], cw.Overflowed)
+	}
+	return w
+}
+
+// saveCheckpoint writes the windows in progress to the checkpoint file, when enabled. The file is replaced atomically.
+func (a *Logger) saveCheckpoint(w *windowState, duration time.Duration, rollups []*rollup) {
+	path := a.config().checkpointPath
+	if path == "" {
+		return
+	}
+	cp := checkpoint{Version: checkpointVersion, Saved: time.Now(), Windows: []checkpointWindow{w.checkpoint(duration, false, 0)}}
+	for _, r := range rollups {
+		cp.Windows = append(cp.Windows, r.w.checkpoint(r.interval, true, r.elapsed))
+	}
+	if err := writeCheckpoint(path, cp); err != nil {
+		slog.Warn("aggregation checkpoint failed", slog.Any("error", err))
+	}
+}
+
+// writeCheckpoint writes a checkpoint to a temporary file renamed to path.
+func writeCheckpoint(path string, cp checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// readCheckpoint reads a checkpoint file. It returns nil and no error when the file does not exist.
+func readCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("slogger: invalid checkpoint %s: %w", path, err)
+	}
+	if cp.Version != checkpointVersion {
+		return nil, fmt.Errorf("slogger: unsupported checkpoint version %d in %s", cp.Version, path)
+	}
+	return &cp, nil
+}
+
+// restoreCheckpoint restores the windows of the checkpoint file that are still open into the window and rollups in
+// progress, and emits the windows that ended with recovered=true. Ended windows of the aggregation interval are
+// merged into the saved rollups first, since a rollup only includes the windows that ended before it was saved.
+// It reports whether the window in progress was restored, in which case it ends at its saved start plus duration.
+func (a *Logger) restoreCheckpoint(w *windowState, duration time.Duration, rollups []*rollup) (restored bool) {
+	path := a.config().checkpointPath
+	if path == "" {
+		return false
+	}
+	cp, err := readCheckpoint(path)
+	if err != nil {
+		slog.Warn("aggregation checkpoint not restored", slog.Any("error", err))
+		return false
+	}
+	if cp == nil {
+		return false
+	}
+	now := time.Now()
+	isOpen := func(cw checkpointWindow) bool {
+		return cw.Start.Add(cw.Interval).After(now)
+	}
+
+	var ended []checkpointWindow
+	for _, cw := range cp.Windows {
+		if cw.Rollup {
+			continue
+		}
+		if cw.Interval == duration && isOpen(cw) {
+			a.merge(w, a.restoreWindow(cw))
+			w.start = cw.Start
+			restored = true
+			continue
+		}
+		ended = append(ended, cw)
+		a.printWindow(a.restoreWindow(cw).markRecovered(), cw.Interval)
+	}
+	for _, cw := range cp.Windows {
+		if !cw.Rollup {
+			continue
+		}
+		rw := a.restoreWindow(cw)
+		elapsed := cw.Elapsed
+		for _, e := range ended {
+			a.merge(rw, a.restoreWindow(e))
+			elapsed += e.Interval
+		}
+		i := slices.IndexFunc(rollups, func(r *rollup) bool { return r.interval == cw.Interval })
+		if i < 0 || !isOpen(cw) {
+			a.printWindow(rw.markRecovered(), cw.Interval)
+			continue
+		}
+		rollups[i].w = rw
+		rollups[i].elapsed = elapsed
+	}
+	a.saveCheckpoint(w, duration, rollups)
+	return restored
+}
+
+// markRecovered flags the buckets of a window restored after it had ended.
+func (w *windowState) markRecovered() *windowState {
+	for key, v := range w.entries {
+		v.recovered = true
+		w.entries[key] = v
+	}
+	return w
+}
+
+// removeCheckpoint removes the checkpoint file once the sinks have delivered the windows emitted so far. The file is
+// kept when a sink fails to confirm the delivery before checkpointDeliveryTimeout.
+func (a *Logger) removeCheckpoint() {
+	logConf := a.config()
+	path := logConf.checkpointPath
+	if path == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), checkpointDeliveryTimeout)
+	defer cancel()
+	if err := logConf.flushSinksAndWait(ctx); err != nil {
+		slog.Warn("aggregation checkpoint kept, windows not delivered", slog.Any("error", err))
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("aggregation checkpoint not removed", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,234 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForCheckpoint waits until the checkpoint file holds a window with the given number of buckets.
+func waitForCheckpoint(t *testing.T, path string, buckets int) *checkpoint {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cp, err := readCheckpoint(path); err == nil && cp != nil && len(cp.Windows) > 0 && len(cp.Windows[0].Buckets) == buckets {
+			return cp
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("expected a checkpoint with %d buckets", buckets)
+	return nil
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aggregator.json")
+	opts := []Option{WithTimeAggregation(time.Hour), WithCheckpoint(path, 10*time.Millisecond), WithTopK(2), WithUniqueClients(10), WithMaxAggregationKeys(2), WithDefaultOutput(false)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	logger := New(ctx, opts...)
+	for _, ip := range []string{"10.0.0.1", "10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		logger.send(logEntry{ip: ip, method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK, realtimeDetails: realtimeDetails{latency: time.Millisecond}})
+	}
+	before := logger.Snapshot().Current
+	waitForCheckpoint(t, path, 3)
+
+	// Simulate a crash: keep the checkpoint that a graceful shutdown removes.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	<-logger.Done()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the checkpoint to be removed on shutdown, got %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	restored := New(ctx, opts...)
+	defer func() {
+		cancel()
+		<-restored.Done()
+	}()
+	restored.send(logEntry{ip: "10.0.0.1", method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK, realtimeDetails: realtimeDetails{latency: 3 * time.Millisecond}})
+	after := restored.Snapshot().Current
+
+	if !after.Start.Equal(before.Start) {
+		t.Errorf("expected the window start %v to be restored, got %v", before.Start, after.Start)
+	}
+	if after.Requests != 5 || len(after.Buckets) != 3 {
+		t.Fatalf("expected 5 requests in 3 buckets, got %+v", after)
+	}
+	if b := after.Buckets[0]; b.IP != "10.0.0.1" || b.Count != 3 || b.MaxLatency != 3*time.Millisecond {
+		t.Errorf("unexpected restored bucket %+v", b)
+	}
+	if after.OverflowedKeys != 1 {
+		t.Errorf("expected 1 overflowed key, got %d", after.OverflowedKeys)
+	}
+	if len(after.Top.IPs) != 2 || after.Top.IPs[0] != (HeavyHitter{Key: "10.0.0.1", Count: 3}) {
+		t.Errorf("unexpected top IPs %v", after.Top.IPs)
+	}
+	if len(after.Routes) != 1 || after.Routes[0].Requests != 5 || after.Routes[0].UniqueClients != 3 {
+		t.Errorf("unexpected routes %+v", after.Routes)
+	}
+}
+
+func TestCheckpointRecoversEndedWindows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aggregator.json")
+	start := time.Now().Add(-2 * time.Hour).UTC().Truncate(time.Second)
+	bucket := checkpointBucket{Created: start, LastMod: start, IP: "10.0.0.1", UserAgent: "curl", Method: http.MethodGet, AggregatePath: "/api", StatusCode: http.StatusOK, Count: 7, SumLatency: 7 * time.Millisecond, MinLatency: time.Millisecond, MaxLatency: time.Millisecond}
+	err := writeCheckpoint(path, checkpoint{Version: checkpointVersion, Saved: start, Windows: []checkpointWindow{
+		{Start: start, Interval: time.Minute, Buckets: []checkpointBucket{bucket}},
+		{Start: start, Interval: time.Hour, Rollup: true, Elapsed: 30 * time.Minute, Buckets: []checkpointBucket{bucket}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf lockedBuffer
+	recovered := make(chan Entry, 10)
+	logger := New(ctx, WithTimeAggregation(time.Minute, time.Hour), WithCheckpoint(path, time.Hour),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+		WithSink(SinkFunc(func(e Entry) error {
+			recovered <- e
+			return nil
+		}), SinkResolutions(time.Minute, time.Hour)))
+	defer func() {
+		cancel()
+		<-logger.Done()
+	}()
+
+	// The ended window is emitted, then merged into the rollup it belongs to.
+	for _, expected := range []struct {
+		resolution time.Duration
+		count      int
+	}{{time.Minute, 7}, {time.Hour, 14}} {
+		select {
+		case e := <-recovered:
+			if !e.Recovered() || e.Count() != expected.count || e.Resolution() != expected.resolution || !e.Time().Equal(start) {
+				t.Errorf("unexpected recovered entry: recovered %v, count %d, resolution %v, time %v", e.Recovered(), e.Count(), e.Resolution(), e.Time())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected a recovered entry")
+		}
+	}
+	if out := buf.String(); strings.Count(out, "recovered=true") != 2 {
+		t.Errorf("expected 2 recovered lines, got %s", out)
+	}
+	if s := logger.Snapshot(); s.Current.Requests != 0 {
+		t.Errorf("expected ended windows not to be restored, got %+v", s.Current)
+	}
+
+	cp, err := readCheckpoint(path)
+	if err != nil || cp == nil {
+		t.Fatalf("expected a checkpoint to be saved after recovery, got %v", err)
+	}
+	for _, cw := range cp.Windows {
+		if len(cw.Buckets) != 0 {
+			t.Errorf("expected recovered windows not to be saved again, got %+v", cw)
+		}
+	}
+}
+
+func TestCheckpointRestoredWindowEndsOnTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aggregator.json")
+	start := time.Now().Add(-900 * time.Millisecond)
+	bucket := checkpointBucket{Created: start, LastMod: start, IP: "10.0.0.1", UserAgent: "curl", Method: http.MethodGet, AggregatePath: "/api", StatusCode: http.StatusOK, Count: 3}
+	err := writeCheckpoint(path, checkpoint{Version: checkpointVersion, Saved: start, Windows: []checkpointWindow{
+		{Start: start, Interval: time.Second, Buckets: []checkpointBucket{bucket}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	entries := make(chan Entry, 10)
+	logger := New(ctx, WithTimeAggregation(time.Second), WithCheckpoint(path, time.Hour), WithDefaultOutput(false),
+		WithSink(SinkFunc(func(e Entry) error {
+			entries <- e
+			return nil
+		})))
+	defer func() {
+		cancel()
+		<-logger.Done()
+	}()
+
+	// The restored window ends a second after its saved start, not a full interval after the restart.
+	select {
+	case e := <-entries:
+		if e.Recovered() || e.Count() != 3 || !e.Time().Equal(start) || e.Resolution() != time.Second {
+			t.Errorf("unexpected window: recovered %v, count %d, time %v, resolution %v", e.Recovered(), e.Count(), e.Time(), e.Resolution())
+		}
+	case <-time.After(600 * time.Millisecond):
+		t.Fatal("expected the restored window to end at its own boundary")
+	}
+}
+
+func TestCheckpointKeptUntilDelivered(t *testing.T) {
+	defer func(timeout time.Duration) { checkpointDeliveryTimeout = timeout }(checkpointDeliveryTimeout)
+	checkpointDeliveryTimeout = 20 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "aggregator.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	logger := New(ctx, WithTimeAggregation(time.Hour), WithCheckpoint(path, time.Hour), WithDefaultOutput(false),
+		WithSink(SinkFunc(func(e Entry) error {
+			<-release
+			return nil
+		})))
+	logger.send(logEntry{ip: "10.0.0.1", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	logger.send(logEntry{ip: "10.0.0.2", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	_ = logger.Snapshot()
+	cancel()
+
+	// The sink does not confirm the delivery of the last window, so the checkpoint holding it is kept.
+	select {
+	case <-logger.aggregatorDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the aggregator to stop")
+	}
+	cp, err := readCheckpoint(path)
+	if err != nil || cp == nil || len(cp.Windows) == 0 || len(cp.Windows[0].Buckets) != 2 {
+		t.Errorf("expected the checkpoint with the last window to be kept, got %+v %v", cp, err)
+	}
+	close(release)
+	<-logger.Done()
+}
+
+func TestCheckpointInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aggregator.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCheckpoint(path); err == nil {
+		t.Error("expected an error for an invalid checkpoint")
+	}
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCheckpoint(path); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+	if cp, err := readCheckpoint(filepath.Join(t.TempDir(), "missing.json")); cp != nil || err != nil {
+		t.Errorf("expected no checkpoint and no error for a missing file, got %v %v", cp, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	logger := New(ctx, WithTimeAggregation(time.Hour), WithCheckpoint(path, time.Hour), WithDefaultOutput(false))
+	defer func() {
+		cancel()
+		<-logger.Done()
+	}()
+	logger.send(logEntry{ip: "10.0.0.1", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	if s := logger.Snapshot(); s.Current.Requests != 1 {
+		t.Errorf("expected the aggregator to run without the invalid checkpoint, got %+v", s.Current)
+	}
+}
@@ -3,6 +3,7 @@ package slogger
 import (
 	"log/slog"
 	"os"
+	"slices"
 	"time"
 )
 
@@ -12,6 +13,9 @@ type Option func(c *conf)
 // TimeSource represents a function that returns the current time as a time.Time value.
 type TimeSource func() time.Time
 
+// LevelFunc returns the level an entry is logged at, given its status code and whether the request was slow.
+type LevelFunc func(statusCode int, isSlow bool) slog.Level
+
 // BotDetector is an interface used to identify whether a given user agent string corresponds to a bot or not.
 type BotDetector interface {
 	IsBot(userAgent string) bool
@@ -19,27 +23,57 @@ type BotDetector interface {
 
 // conf represents the configuration options for the application, including logging, bot detection, and path handling.
 type conf struct {
-	botDetectionService  BotDetector
-	logQueryString       bool
-	excludedPaths        []string
-	logHeaders           bool
-	aggregationQueueSize int
-	defaultLogMessage    string
-	loggingHandler       *slog.Logger
-	clientIPHeaders      []string
-	logHeadersWithName   map[string][]string
-	pathMappingFunction  func(route string, path string, statusCode int) string
-	isAggregationEnabled bool
-	aggregationInterval  time.Duration
-	userAgentHeaders     []string
-	staticLogEntries     map[string]string
+	botDetectionService    BotDetector
+	logQueryString         bool
+	requestBodyLimit       int
+	excludedPaths          []string
+	logHeaders             bool
+	aggregationQueueSize   int
+	defaultLogMessage      string
+	loggingHandler         *slog.Logger
+	clientIPHeaders        []string
+	logHeadersWithName     map[string][]string
+	pathMappingFunction    func(route string, path string, statusCode int) string
+	isAggregationEnabled   bool
+	aggregationInterval    time.Duration
+	userAgentHeaders       []string
+	staticLogEntries       map[string]string
+	slowThreshold          time.Duration
+	slowRouteThresholds    map[string]time.Duration
+	sampleRatio            *float64
+	routeSampleRatios      map[string]float64
+	rateLimiter            *rateLimiter
+	routingPolicy          RoutingPolicy
+	skipRules              []SkipRule
+	countSkipped           bool
+	levelFunc              LevelFunc
+	schema                 Schema
+	accessLogs             []*AccessLogWriter
+	sinks                  []*sinkWorker
+	disableDefaultOutput   bool
+	onEntryHooks           []func(e *Entry)
+	afterEntryHooks        []func(e Entry)
+	metrics                *Metrics
+	async                  *asyncConf
+	pathMappingSetBy       []string
+	snapshotHistory        int
+	topK                   int
+	maxAggregationKeys     int
+	uniqueClientsPrecision int
+	rollupIntervals        []time.Duration
+	checkpointPath         string
+	checkpointInterval     time.Duration
 }
 
 // WithTimeAggregation sets the time duration for aggregation and enables the aggregation feature in the configuration.
-func WithTimeAggregation(every time.Duration) Option {
+// Optional rollup intervals, multiples of every such as time.Minute and time.Hour, emit larger windows built by merging
+// the windows of every; aggregated lines are then tagged with their resolution. Sinks receive the windows of every only,
+// unless they ask for rollups with SinkResolutions.
+func WithTimeAggregation(every time.Duration, rollups ...time.Duration) Option {
 	return func(c *conf) {
 		c.isAggregationEnabled = true
 		c.aggregationInterval = every
+		c.rollupIntervals = slices.Sorted(slices.Values(rollups))
 	}
 }
 
@@ -68,6 +102,7 @@ func WithBotDetector(detector BotDetector) Option {
 func WithAggregatePath(pathFunc func(router, path string, statusCode int) string) Option {
 	return func(c *conf) {
 		c.pathMappingFunction = pathFunc
+		c.pathMappingSetBy = append(c.pathMappingSetBy, "WithAggregatePath")
 	}
 }
 
@@ -78,6 +113,14 @@ func WithLogQueryString(log bool) Option {
 	}
 }
 
+// WithLogRequestBody logs up to maxBytes of the request body in realtime entries. The body is read before the
+// handlers run and replayed to them. Zero disables body capture.
+func WithLogRequestBody(maxBytes int) Option {
+	return func(c *conf) {
+		c.requestBodyLimit = maxBytes
+	}
+}
+
 // WithSkipPaths sets the excludedPaths field in the configuration to exclude specific paths from processing.
 func WithSkipPaths(paths []string) Option {
 	return func(c *conf) {
@@ -134,6 +177,7 @@ func WithHeaderToLogs(headerToLogs map[string][]string) Option {
 func WithPathAggregator(pathAggregator func(route string, path string, statusCode int) string) Option {
 	return func(c *conf) {
 		c.pathMappingFunction = pathAggregator
+		c.pathMappingSetBy = append(c.pathMappingSetBy, "WithPathAggregator")
 	}
 }
 
@@ -144,6 +188,86 @@ func WithStaticLogEntries(entries map[string]string) Option {
 	}
 }
 
+// WithSlowThreshold marks requests slower than threshold as slow. perRoute overrides the threshold for specific routes, keyed by c.FullPath().
+// Slow requests are always logged in realtime, even when aggregation is enabled.
+func WithSlowThreshold(threshold time.Duration, perRoute map[string]time.Duration) Option {
+	return func(c *conf) {
+		c.slowThreshold = threshold
+		c.slowRouteThresholds = perRoute
+	}
+}
+
+// isSlowDetectionEnabled reports whether a global or per-route slow threshold has been configured.
+func (c *conf) isSlowDetectionEnabled() bool {
+	return c.slowThreshold > 0 || len(c.slowRouteThresholds) > 0
+}
+
+// isSlow reports whether latency exceeds the slow threshold of the given route, falling back to the global threshold.
+func (c *conf) isSlow(route string, latency time.Duration) bool {
+	threshold := c.slowThreshold
+	if t, ok := c.slowRouteThresholds[route]; ok {
+		threshold = t
+	}
+	if threshold <= 0 {
+		return false
+	}
+	return latency > threshold
+}
+
+// WithLevel sets a fixed level for all log entries. The default level is slog.LevelInfo.
+func WithLevel(level slog.Level) Option {
+	return func(c *conf) {
+		c.levelFunc = func(int, bool) slog.Level {
+			return level
+		}
+	}
+}
+
+// WithLevelFunc sets a function choosing the level of each log entry, eg: StatusLevels.
+func WithLevelFunc(levelFunc LevelFunc) Option {
+	return func(c *conf) {
+		c.levelFunc = levelFunc
+	}
+}
+
+// StatusLevels returns a LevelFunc logging 5xx responses at error level, 4xx responses and slow requests at warn level and the rest at info level.
+func StatusLevels() LevelFunc {
+	return func(statusCode int, isSlow bool) slog.Level {
+		switch {
+		case statusCode >= 500:
+			return slog.LevelError
+		case statusCode >= 400 || isSlow:
+			return slog.LevelWarn
+		default:
+			return slog.LevelInfo
+		}
+	}
+}
+
+// level returns the level of an entry, defaulting to slog.LevelInfo when no level function is configured.
+func (c *conf) level(statusCode int, isSlow bool) slog.Level {
+	if c.levelFunc == nil {
+		return slog.LevelInfo
+	}
+	return c.levelFunc(statusCode, isSlow)
+}
+
+// WithOnEntry adds a hook run before each entry, realtime or aggregated, is emitted.
+// The hook can change the level, message and tags of the entry, or drop it.
+func WithOnEntry(hook func(e *Entry)) Option {
+	return func(c *conf) {
+		c.onEntryHooks = append(c.onEntryHooks, hook)
+	}
+}
+
+// WithAfterEntry adds a hook run after each entry, realtime or aggregated, has been emitted, eg: for alerting.
+// The hook runs on the goroutine emitting the entry, so it must not block.
+func WithAfterEntry(hook func(e Entry)) Option {
+	return func(c *conf) {
+		c.afterEntryHooks = append(c.afterEntryHooks, hook)
+	}
+}
+
 // configure sets up the configuration for the application logger with the provided name, version, and optional settings.
 func configure(opts ...Option) *conf {
 	c := &conf{
@@ -166,9 +290,11 @@ func configure(opts ...Option) *conf {
 		userAgentHeaders:     []string{},            //[]string{"x-user-agent", "user-agent"},
 		logHeadersWithName:   map[string][]string{}, //map[string][]string{"country": {"x-cf-ipcountry", "cf-ipcountry"},"referer": {"x-referer", "referer"},},
 		staticLogEntries:     map[string]string{},
+		slowRouteThresholds:  map[string]time.Duration{},
 		isAggregationEnabled: false,
 		aggregationQueueSize: 100,
 		aggregationInterval:  10 * time.Second,
+		snapshotHistory:      defaultSnapshotHistory,
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -0,0 +1,386 @@
+package slogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a declarative configuration mapping onto the With* options, eg: loaded from a YAML or JSON file with
+// LoadConfigFile or from environment variables with FromEnv. Options that take code, such as hooks, sinks and bot
+// detectors, are not part of Config and can be appended to the options returned by FromConfig.
+type Config struct {
+	Aggregation         *bool               `yaml:"aggregation" json:"aggregation" env:"AGGREGATION"`
+	AggregationInterval *Duration           `yaml:"aggregationInterval" json:"aggregationInterval" env:"AGGREGATION_INTERVAL"`
+	QueueSize           *int                `yaml:"queueSize" json:"queueSize" env:"QUEUE_SIZE"`
+	LogQueryString      bool                `yaml:"logQueryString" json:"logQueryString" env:"LOG_QUERY_STRING"`
+	LogHeaders          bool                `yaml:"logHeaders" json:"logHeaders" env:"LOG_HEADERS"`
+	LogRequestBody      int                 `yaml:"logRequestBody" json:"logRequestBody" env:"LOG_REQUEST_BODY"`
+	HeaderToLogs        map[string][]string `yaml:"headerToLogs" json:"headerToLogs" env:"HEADER_TO_LOGS"`
+	IPHeaders           []string            `yaml:"ipHeaders" json:"ipHeaders" env:"IP_HEADERS"`
+	UAHeaders           []string            `yaml:"uaHeaders" json:"uaHeaders" env:"UA_HEADERS"`
+	StaticLogEntries    map[string]string   `yaml:"staticLogEntries" json:"staticLogEntries" env:"STATIC_LOG_ENTRIES"`
+	SkipPaths           []string            `yaml:"skipPaths" json:"skipPaths" env:"SKIP_PATHS"`
+	SkipPathPrefixes    []string            `yaml:"skipPathPrefixes" json:"skipPathPrefixes" env:"SKIP_PATH_PREFIXES"`
+	SkipRouteGlobs      []string            `yaml:"skipRouteGlobs" json:"skipRouteGlobs" env:"SKIP_ROUTE_GLOBS"`
+	SkipPathRegexp      string              `yaml:"skipPathRegexp" json:"skipPathRegexp" env:"SKIP_PATH_REGEXP"`
+	SkipMethods         []string            `yaml:"skipMethods" json:"skipMethods" env:"SKIP_METHODS"`
+	SkipStatusCodes     []int               `yaml:"skipStatusCodes" json:"skipStatusCodes" env:"SKIP_STATUS_CODES"`
+	SkipUserAgents      []string            `yaml:"skipUserAgents" json:"skipUserAgents" env:"SKIP_USER_AGENTS"`
+	SkippedCounter      bool                `yaml:"skippedCounter" json:"skippedCounter" env:"SKIPPED_COUNTER"`
+	SlowThreshold       Duration            `yaml:"slowThreshold" json:"slowThreshold" env:"SLOW_THRESHOLD"`
+	SlowRouteThresholds map[string]Duration `yaml:"slowRouteThresholds" json:"slowRouteThresholds" env:"SLOW_ROUTE_THRESHOLDS"`
+	SampleRatio         *float64            `yaml:"sampleRatio" json:"sampleRatio" env:"SAMPLE_RATIO"`
+	RouteSampleRatios   map[string]float64  `yaml:"routeSampleRatios" json:"routeSampleRatios" env:"ROUTE_SAMPLE_RATIOS"`
+	Level               string              `yaml:"level" json:"level" env:"LEVEL"`
+	StatusLevels        bool                `yaml:"statusLevels" json:"statusLevels" env:"STATUS_LEVELS"`
+	Schema              string              `yaml:"schema" json:"schema" env:"SCHEMA"`
+}
+
+// Duration is a time.Duration written as a string in configuration files, eg: "10s" or "1m30s".
+type Duration time.Duration
+
+// UnmarshalText parses a duration with time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats the duration with time.Duration.String.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// ValidationError reports an invalid configuration value.
+type ValidationError struct {
+	Field  string
+	Value  any
+	Reason string
+}
+
+// Error returns the field, the value and the reason, eg: `slogger: invalid queueSize -1: must be positive`.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("slogger: invalid %s %v: %s", e.Field, e.Value, e.Reason)
+}
+
+// schemas maps the Config.Schema names to the schemas.
+var schemas = map[string]func() Schema{
+	"":        DefaultSchema,
+	"default": DefaultSchema,
+	"ecs":     ECSSchema,
+	"otel":    OTelSchema,
+	"gcp":     GCPSchema,
+}
+
+// Validate checks the configuration values. It returns every ValidationError found, joined with errors.Join.
+func (cfg Config) Validate() error {
+	var errs []error
+	invalid := func(field string, value any, reason string) {
+		errs = append(errs, &ValidationError{Field: field, Value: value, Reason: reason})
+	}
+
+	if cfg.AggregationInterval != nil && *cfg.AggregationInterval <= 0 {
+		invalid("aggregationInterval", time.Duration(*cfg.AggregationInterval), "must be positive")
+	}
+	if cfg.QueueSize != nil && *cfg.QueueSize <= 0 {
+		invalid("queueSize", *cfg.QueueSize, "must be positive")
+	}
+	if cfg.SlowThreshold < 0 {
+		invalid("slowThreshold", time.Duration(cfg.SlowThreshold), "must not be negative")
+	}
+	for route, threshold := range cfg.SlowRouteThresholds {
+		if threshold <= 0 {
+			invalid("slowRouteThresholds["+route+"]", time.Duration(threshold), "must be positive")
+		}
+	}
+	if cfg.SampleRatio != nil && (*cfg.SampleRatio < 0 || *cfg.SampleRatio > 1) {
+		invalid("sampleRatio", *cfg.SampleRatio, "must be between 0 and 1")
+	}
+	for route, ratio := range cfg.RouteSampleRatios {
+		if ratio < 0 || ratio > 1 {
+			invalid("routeSampleRatios["+route+"]", ratio, "must be between 0 and 1")
+		}
+	}
+	if cfg.Level != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			invalid("level", cfg.Level, "must be debug, info, warn or error")
+		}
+		if cfg.StatusLevels {
+			invalid("level", cfg.Level, "cannot be used with statusLevels")
+		}
+	}
+	if _, ok := schemas[strings.ToLower(cfg.Schema)]; !ok {
+		invalid("schema", cfg.Schema, "must be default, ecs, otel or gcp")
+	}
+	if cfg.SkipPathRegexp != "" {
+		if _, err := regexp.Compile(cfg.SkipPathRegexp); err != nil {
+			invalid("skipPathRegexp", cfg.SkipPathRegexp, err.Error())
+		}
+	}
+	for _, code := range cfg.SkipStatusCodes {
+		if code < 100 || code > 999 {
+			invalid("skipStatusCodes", code, "must be an HTTP status code")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FromConfig validates cfg and returns the options it maps onto.
+func FromConfig(cfg Config) ([]Option, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	var opts []Option
+	if cfg.AggregationInterval != nil {
+		opts = append(opts, WithTimeAggregation(time.Duration(*cfg.AggregationInterval)))
+	}
+	if cfg.Aggregation != nil {
+		opts = append(opts, WithAggregation(*cfg.Aggregation))
+	}
+	if cfg.QueueSize != nil {
+		opts = append(opts, WithQueueSize(*cfg.QueueSize))
+	}
+	opts = append(opts, WithLogQueryString(cfg.LogQueryString), WithLogHeaders(cfg.LogHeaders), WithLogRequestBody(cfg.LogRequestBody), WithSkippedCounter(cfg.SkippedCounter))
+	if len(cfg.HeaderToLogs) > 0 {
+		opts = append(opts, WithHeaderToLogs(cfg.HeaderToLogs))
+	}
+	if len(cfg.IPHeaders) > 0 {
+		opts = append(opts, WithIpHeaders(cfg.IPHeaders))
+	}
+	if len(cfg.UAHeaders) > 0 {
+		opts = append(opts, WithUaHeaders(cfg.UAHeaders))
+	}
+	if len(cfg.StaticLogEntries) > 0 {
+		opts = append(opts, WithStaticLogEntries(cfg.StaticLogEntries))
+	}
+	if len(cfg.SkipPaths) > 0 {
+		opts = append(opts, WithSkipPaths(cfg.SkipPaths))
+	}
+
+	var rules []SkipRule
+	if len(cfg.SkipPathPrefixes) > 0 {
+		rules = append(rules, SkipPathPrefix(cfg.SkipPathPrefixes...))
+	}
+	if len(cfg.SkipRouteGlobs) > 0 {
+		rules = append(rules, SkipRouteGlob(cfg.SkipRouteGlobs...))
+	}
+	if cfg.SkipPathRegexp != "" {
+		rules = append(rules, SkipPathRegexp(regexp.MustCompile(cfg.SkipPathRegexp)))
+	}
+	if len(cfg.SkipMethods) > 0 {
+		rules = append(rules, SkipMethods(cfg.SkipMethods...))
+	}
+	if len(cfg.SkipStatusCodes) > 0 {
+		rules = append(rules, SkipStatusCodes(cfg.SkipStatusCodes...))
+	}
+	if len(cfg.SkipUserAgents) > 0 {
+		rules = append(rules, SkipUserAgents(cfg.SkipUserAgents...))
+	}
+	if len(rules) > 0 {
+		opts = append(opts, WithSkipRules(rules...))
+	}
+
+	if cfg.SlowThreshold > 0 || len(cfg.SlowRouteThresholds) > 0 {
+		perRoute := make(map[string]time.Duration, len(cfg.SlowRouteThresholds))
+		for route, threshold := range cfg.SlowRouteThresholds {
+			perRoute[route] = time.Duration(threshold)
+		}
+		opts = append(opts, WithSlowThreshold(time.Duration(cfg.SlowThreshold), perRoute))
+	}
+	if cfg.SampleRatio != nil {
+		opts = append(opts, WithSampleRatio(*cfg.SampleRatio))
+	}
+	if len(cfg.RouteSampleRatios) > 0 {
+		opts = append(opts, WithRouteSampleRatios(cfg.RouteSampleRatios))
+	}
+	if cfg.Level != "" {
+		var level slog.Level
+		_ = level.UnmarshalText([]byte(cfg.Level))
+		opts = append(opts, WithLevel(level))
+	}
+	if cfg.StatusLevels {
+		opts = append(opts, WithLevelFunc(StatusLevels()))
+	}
+	if cfg.Schema != "" {
+		opts = append(opts, WithSchema(schemas[strings.ToLower(cfg.Schema)]()))
+	}
+	return opts, nil
+}
+
+// LoadConfigFile reads a YAML (.yaml, .yml) or JSON (.json) configuration file. Unknown keys are reported as errors.
+func LoadConfigFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAMLConfig(data)
+	case ".json":
+		return ParseJSONConfig(data)
+	}
+	return Config{}, fmt.Errorf("slogger: unsupported configuration file extension %q", filepath.Ext(path))
+}
+
+// ParseYAMLConfig decodes a YAML configuration. Unknown keys are reported as errors.
+func ParseYAMLConfig(data []byte) (Config, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("slogger: %w", err)
+	}
+	return cfg, nil
+}
+
+// ParseJSONConfig decodes a JSON configuration. Unknown keys are reported as errors.
+func ParseJSONConfig(data []byte) (Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("slogger: %w", err)
+	}
+	return cfg, nil
+}
+
+// FromEnv reads a Config from the environment variables named prefix_FIELD, eg: with prefix "APILOG",
+// APILOG_AGGREGATION=true and APILOG_SKIP_PATHS=/health,/metrics, and returns the options it maps onto.
+// Lists are comma-separated; maps are comma-separated key=value pairs, with values separated by | for header lists,
+// eg: APILOG_HEADER_TO_LOGS=country=cf-ipcountry|x-country. Unknown variables with the prefix are reported as errors.
+func FromEnv(prefix string) ([]Option, error) {
+	cfg, err := configFromEnv(prefix, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	return FromConfig(cfg)
+}
+
+// configFromEnv decodes a Config from KEY=value pairs.
+func configFromEnv(prefix string, environ []string) (Config, error) {
+	var cfg Config
+	v := reflect.ValueOf(&cfg).Elem()
+	fields := make(map[string]reflect.Value, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		fields[prefix+"_"+v.Type().Field(i).Tag.Get("env")] = v.Field(i)
+	}
+
+	var errs []error
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, prefix+"_") {
+			continue
+		}
+		field, ok := fields[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("slogger: unknown environment variable %s", key))
+			continue
+		}
+		if err := setEnvValue(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("slogger: invalid %s: %w", key, err))
+		}
+	}
+	return cfg, errors.Join(errs...)
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// setEnvValue parses value into a Config field.
+func setEnvValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setEnvValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	if field.Type() == durationType {
+		var d Duration
+		if err := d.UnmarshalText([]byte(value)); err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		items := splitList(value, ",")
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setEnvValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	case reflect.Map:
+		m := reflect.MakeMap(field.Type())
+		for _, pair := range splitList(value, ",") {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if elem.Kind() == reflect.Slice {
+				elem.Set(reflect.ValueOf(splitList(v, "|")))
+			} else if err := setEnvValue(elem, strings.TrimSpace(v)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(k)), elem)
+		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// splitList splits a list and trims its items, ignoring empty items.
+func splitList(s, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,206 @@
+package slogger
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const yamlConfig = `
+aggregation: true
+aggregationInterval: 30s
+queueSize: 500
+logQueryString: true
+headerToLogs:
+  country: [cf-ipcountry, x-country]
+skipPaths: [/health]
+skipMethods: [OPTIONS]
+slowThreshold: 500ms
+slowRouteThresholds:
+  /api/export: 5s
+sampleRatio: 0.5
+level: warn
+schema: ecs
+`
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{"YAML", "logger.yaml", yamlConfig, ""},
+		{"JSON", "logger.json", `{"aggregation": true, "aggregationInterval": "30s", "queueSize": 500, "logQueryString": true,
+			"headerToLogs": {"country": ["cf-ipcountry", "x-country"]}, "skipPaths": ["/health"], "skipMethods": ["OPTIONS"],
+			"slowThreshold": "500ms", "slowRouteThresholds": {"/api/export": "5s"}, "sampleRatio": 0.5, "level": "warn", "schema": "ecs"}`, ""},
+		{"UnknownYAMLKey", "logger.yaml", "aggregation: true\nqueue_size: 10\n", "field queue_size not found"},
+		{"UnknownJSONKey", "logger.json", `{"aggregation": true, "queue_size": 10}`, `unknown field "queue_size"`},
+		{"InvalidDuration", "logger.yaml", "aggregationInterval: soon\n", "invalid duration"},
+		{"UnsupportedExtension", "logger.toml", "", "unsupported configuration file extension"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), test.file)
+			if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfigFile(path)
+			if test.expected != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expected) {
+					t.Fatalf("expected error containing %q, got %v", test.expected, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts, err := FromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := configure(opts...)
+			if !c.isAggregationEnabled || c.aggregationInterval != 30*time.Second || c.aggregationQueueSize != 500 {
+				t.Errorf("unexpected aggregation settings %v %v %d", c.isAggregationEnabled, c.aggregationInterval, c.aggregationQueueSize)
+			}
+			if !c.logQueryString || len(c.logHeadersWithName["country"]) != 2 || len(c.excludedPaths) != 1 || len(c.skipRules) != 1 {
+				t.Errorf("unexpected logging settings")
+			}
+			if c.slowThreshold != 500*time.Millisecond || c.slowRouteThresholds["/api/export"] != 5*time.Second {
+				t.Errorf("unexpected slow thresholds %v %v", c.slowThreshold, c.slowRouteThresholds)
+			}
+			if c.globalSampleRatio() != 0.5 || c.level(200, false) != slog.LevelWarn {
+				t.Errorf("unexpected sampling or level settings")
+			}
+			if _, ok := c.outputSchema().(ecsSchema); !ok {
+				t.Errorf("expected the ECS schema, got %T", c.outputSchema())
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	zero := Duration(0)
+	negative := -1
+	ratio := 1.5
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected []string
+	}{
+		{"Valid", Config{Level: "error", Schema: "otel"}, nil},
+		{"ZeroInterval", Config{AggregationInterval: &zero}, []string{"aggregationInterval"}},
+		{"NegativeQueueSize", Config{QueueSize: &negative}, []string{"queueSize"}},
+		{"SampleRatioOutOfRange", Config{SampleRatio: &ratio, RouteSampleRatios: map[string]float64{"/a": -1}}, []string{"sampleRatio", "routeSampleRatios[/a]"}},
+		{"UnknownLevel", Config{Level: "verbose"}, []string{"level"}},
+		{"LevelAndStatusLevels", Config{Level: "info", StatusLevels: true}, []string{"level"}},
+		{"UnknownSchema", Config{Schema: "splunk"}, []string{"schema"}},
+		{"InvalidRegexp", Config{SkipPathRegexp: "("}, []string{"skipPathRegexp"}},
+		{"InvalidStatusCode", Config{SkipStatusCodes: []int{42}}, []string{"skipStatusCodes"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.cfg.Validate()
+			if len(test.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			for _, field := range test.expected {
+				var found bool
+				for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+					var ve *ValidationError
+					if errors.As(e, &ve) && ve.Field == field {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected a ValidationError for %s, got %v", field, err)
+				}
+			}
+			if _, err := FromConfig(test.cfg); err == nil {
+				t.Error("expected FromConfig to fail")
+			}
+		})
+	}
+}
+
+func TestFromConfigAggregation(t *testing.T) {
+	enabled, disabled := true, false
+	interval := Duration(30 * time.Second)
+
+	tests := []struct {
+		name             string
+		cfg              Config
+		expectedEnabled  bool
+		expectedInterval time.Duration
+	}{
+		{"Default", Config{}, false, 10 * time.Second},
+		{"IntervalOnly", Config{AggregationInterval: &interval}, true, 30 * time.Second},
+		{"EnabledOnly", Config{Aggregation: &enabled}, true, 10 * time.Second},
+		{"DisabledWithInterval", Config{Aggregation: &disabled, AggregationInterval: &interval}, false, 30 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := FromConfig(test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := configure(opts...)
+			if c.isAggregationEnabled != test.expectedEnabled || c.aggregationInterval != test.expectedInterval {
+				t.Errorf("expected aggregation %v every %v, got %v every %v", test.expectedEnabled, test.expectedInterval, c.isAggregationEnabled, c.aggregationInterval)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	cfg, err := configFromEnv("APILOG", []string{
+		"PATH=/usr/bin",
+		"APILOG_AGGREGATION=true",
+		"APILOG_AGGREGATION_INTERVAL=1m",
+		"APILOG_QUEUE_SIZE=50",
+		"APILOG_SKIP_PATHS=/health, /metrics",
+		"APILOG_SKIP_STATUS_CODES=404,405",
+		"APILOG_HEADER_TO_LOGS=country=cf-ipcountry|x-country,referer=referer",
+		"APILOG_SLOW_ROUTE_THRESHOLDS=/api/export=5s",
+		"APILOG_SAMPLE_RATIO=0.25",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Aggregation == nil || !*cfg.Aggregation || time.Duration(*cfg.AggregationInterval) != time.Minute || *cfg.QueueSize != 50 || *cfg.SampleRatio != 0.25 {
+		t.Errorf("unexpected scalar values %+v", cfg)
+	}
+	if strings.Join(cfg.SkipPaths, " ") != "/health /metrics" || len(cfg.SkipStatusCodes) != 2 || cfg.SkipStatusCodes[1] != 405 {
+		t.Errorf("unexpected lists %v %v", cfg.SkipPaths, cfg.SkipStatusCodes)
+	}
+	if strings.Join(cfg.HeaderToLogs["country"], " ") != "cf-ipcountry x-country" || cfg.HeaderToLogs["referer"][0] != "referer" {
+		t.Errorf("unexpected header map %v", cfg.HeaderToLogs)
+	}
+	if time.Duration(cfg.SlowRouteThresholds["/api/export"]) != 5*time.Second {
+		t.Errorf("unexpected duration map %v", cfg.SlowRouteThresholds)
+	}
+
+	_, err = configFromEnv("APILOG", []string{"APILOG_QUEUESIZE=1", "APILOG_AGGREGATION=maybe"})
+	if err == nil || !strings.Contains(err.Error(), "unknown environment variable APILOG_QUEUESIZE") || !strings.Contains(err.Error(), "invalid APILOG_AGGREGATION") {
+		t.Errorf("expected unknown and invalid variable errors, got %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("TESTLOG_QUEUE_SIZE", "-5")
+	if _, err := FromEnv("TESTLOG"); err == nil || !strings.Contains(err.Error(), "invalid queueSize -5") {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
@@ -499,3 +499,58 @@ func TestWithHeaderToLogs(t *testing.T) {
 		})
 	}
 }
+
+func TestWithSlowThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		perRoute  map[string]time.Duration
+		route     string
+		latency   time.Duration
+		expected  bool
+	}{
+		{"Disabled", 0, nil, "/api", time.Hour, false},
+		{"GlobalBelowThreshold", time.Second, nil, "/api", 500 * time.Millisecond, false},
+		{"GlobalAboveThreshold", time.Second, nil, "/api", 2 * time.Second, true},
+		{"RouteOverrideBelow", time.Second, map[string]time.Duration{"/upload": 10 * time.Second}, "/upload", 2 * time.Second, false},
+		{"RouteOverrideAbove", 0, map[string]time.Duration{"/api": 100 * time.Millisecond}, "/api", 200 * time.Millisecond, true},
+		{"RouteOverrideOtherRoute", 0, map[string]time.Duration{"/api": 100 * time.Millisecond}, "/other", time.Hour, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &conf{}
+			opt := WithSlowThreshold(test.threshold, test.perRoute)
+			opt(c)
+			if result := c.isSlow(test.route, test.latency); result != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []Option
+		statusCode int
+		isSlow     bool
+		expected   slog.Level
+	}{
+		{"Default", nil, 500, false, slog.LevelInfo},
+		{"FixedLevel", []Option{WithLevel(slog.LevelDebug)}, 500, false, slog.LevelDebug},
+		{"StatusLevelsSuccess", []Option{WithLevelFunc(StatusLevels())}, 200, false, slog.LevelInfo},
+		{"StatusLevelsSlow", []Option{WithLevelFunc(StatusLevels())}, 200, true, slog.LevelWarn},
+		{"StatusLevelsClientError", []Option{WithLevelFunc(StatusLevels())}, 404, false, slog.LevelWarn},
+		{"StatusLevelsServerError", []Option{WithLevelFunc(StatusLevels())}, 502, false, slog.LevelError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := configure(test.options...)
+			if result := c.level(test.statusCode, test.isSlow); result != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package slogger
+
+import (
+	"log/slog"
+	"maps"
+	"time"
+)
+
+// Entry is a view of a log line, realtime or aggregated, as delivered to hooks and sinks.
+// Hooks registered with WithOnEntry can modify it through SetLevel, SetMessage, SetTag and Drop before it is emitted.
+// Maps returned by its accessors are copies.
+type Entry struct {
+	msg     string
+	e       logEntry
+	c       *conf
+	dropped bool
+}
+
+// newEntry wraps a log entry with the message and configuration it is emitted with.
+func newEntry(msg string, v logEntry, c *conf) Entry {
+	return Entry{msg: msg, e: v, c: c}
+}
+
+// Message returns the log message.
+func (e Entry) Message() string { return e.msg }
+
+// Level returns the level of the entry.
+func (e Entry) Level() slog.Level { return e.e.level }
+
+// Time returns the request start time for realtime entries, or the bucket creation time for aggregated entries.
+func (e Entry) Time() time.Time { return e.e.created }
+
+// IsAggregate reports whether the entry is an aggregation bucket rather than a single request.
+func (e Entry) IsAggregate() bool { return e.e.isAggregate }
+
+// IsSummary reports whether the entry is a window summary, eg: the heavy hitters of a window, rather than a request or
+// a bucket. Summaries are aggregated entries with a zero Count; their fields are available through Attrs.
+func (e Entry) IsSummary() bool { return e.e.summary != nil }
+
+// IP returns the client IP.
+func (e Entry) IP() string { return e.e.ip }
+
+// RemoteIP returns the IP of the connection peer.
+func (e Entry) RemoteIP() string { return e.e.remoteIp }
+
+// UserAgent returns the user agent.
+func (e Entry) UserAgent() string { return e.e.ua }
+
+// Method returns the HTTP method.
+func (e Entry) Method() string { return e.e.method }
+
+// Proto returns the HTTP protocol, eg: "HTTP/1.1".
+func (e Entry) Proto() string { return e.e.proto }
+
+// Route returns the matched route (c.FullPath()) of a realtime entry.
+func (e Entry) Route() string { return e.e.route }
+
+// AggregatePath returns the path computed by the path aggregation function.
+func (e Entry) AggregatePath() string { return e.e.aggregatePath }
+
+// Path returns the URL path of a realtime entry.
+func (e Entry) Path() string { return e.e.path }
+
+// Query returns the raw query string of a realtime entry.
+func (e Entry) Query() string { return e.e.queryString }
+
+// RequestBody returns the request body captured with WithLogRequestBody, or an empty string.
+func (e Entry) RequestBody() string { return e.e.requestBody }
+
+// Referer returns the referer of a realtime entry.
+func (e Entry) Referer() string { return e.e.referer }
+
+// StatusCode returns the response status code.
+func (e Entry) StatusCode() int { return e.e.statusCode }
+
+// Count returns the number of requests represented by the entry: 1 for realtime entries.
+func (e Entry) Count() int { return e.e.count }
+
+// Latency returns the latency of a realtime entry, or the mean latency of an aggregated entry.
+func (e Entry) Latency() time.Duration {
+	if e.e.isAggregate {
+		return e.e.meanLatency()
+	}
+	return e.e.latency
+}
+
+// MinLatency returns the minimum latency of an aggregated entry, or the latency of a realtime entry.
+func (e Entry) MinLatency() time.Duration {
+	if e.e.isAggregate {
+		return e.e.minLatency
+	}
+	return e.e.latency
+}
+
+// MaxLatency returns the maximum latency of an aggregated entry, or the latency of a realtime entry.
+func (e Entry) MaxLatency() time.Duration {
+	if e.e.isAggregate {
+		return e.e.maxLatency
+	}
+	return e.e.latency
+}
+
+// SumLatency returns the total latency of an aggregated entry, or the latency of a realtime entry.
+func (e Entry) SumLatency() time.Duration {
+	if e.e.isAggregate {
+		return e.e.sumLatency
+	}
+	return e.e.latency
+}
+
+// ResponseSize returns the response body size of a realtime entry, or the total response body size of an aggregated entry.
+func (e Entry) ResponseSize() int {
+	if e.e.isAggregate {
+		return e.e.sumSizeRespoBody
+	}
+	return e.e.responseBodySize
+}
+
+// IsSlow reports whether a realtime entry exceeded its slow threshold.
+func (e Entry) IsSlow() bool { return e.e.isSlow }
+
+// SlowCount returns the number of slow requests of an aggregated entry, or 1 for a slow realtime entry.
+func (e Entry) SlowCount() int {
+	if e.e.isAggregate {
+		return e.e.slowCount
+	}
+	if e.e.isSlow {
+		return 1
+	}
+	return 0
+}
+
+// IsBot reports whether the user agent was flagged as a bot. detected is false when no BotDetector is configured.
+func (e Entry) IsBot() (isBot bool, detected bool) {
+	return e.e.isBot == 1, e.e.isBotDetectorEnabled
+}
+
+// SampleRate returns the rate the entry was sampled at: each entry stands for 1/SampleRate requests.
+func (e Entry) SampleRate() float64 { return e.e.effectiveSampleRate() }
+
+// Headers returns the request headers of a realtime entry when header logging is enabled.
+func (e Entry) Headers() map[string]string { return maps.Clone(e.e.headers) }
+
+// Fields returns the named header values configured with WithHeaderToLogs that were found in the request.
+func (e Entry) Fields() map[string]string {
+	fields := make(map[string]string, len(e.e.extraFields))
+	for key, value := range e.e.extraFields {
+		if value.found {
+			fields[key] = value.value
+		}
+	}
+	return fields
+}
+
+// StaticFields returns the static entries configured with WithStaticLogEntries.
+func (e Entry) StaticFields() map[string]string {
+	if e.c == nil {
+		return map[string]string{}
+	}
+	return maps.Clone(e.c.staticLogEntries)
+}
+
+// Tags returns the tags added by hooks with SetTag.
+func (e Entry) Tags() map[string]string {
+	if e.e.tags == nil {
+		return map[string]string{}
+	}
+	return maps.Clone(e.e.tags)
+}
+
+// SetTag adds a key/value pair emitted as an attribute of the entry.
+func (e *Entry) SetTag(key, value string) {
+	tags := maps.Clone(e.e.tags)
+	if tags == nil {
+		tags = make(map[string]string, 1)
+	}
+	tags[key] = value
+	e.e.tags = tags
+}
+
+// SetLevel changes the level the entry is emitted at.
+func (e *Entry) SetLevel(level slog.Level) { e.e.level = level }
+
+// SetMessage changes the log message of the entry.
+func (e *Entry) SetMessage(msg string) { e.msg = msg }
+
+// Drop prevents the entry from being emitted. Later hooks are not run.
+func (e *Entry) Drop() { e.dropped = true }
+
+// Dropped reports whether a hook dropped the entry.
+func (e Entry) Dropped() bool { return e.dropped }
+
+// Attrs returns the attributes of the entry as rendered by the configured Schema, including named headers, static entries and tags.
+func (e Entry) Attrs() []any {
+	attrs := logAttrs(e)
+	args := make([]any, len(attrs))
+	for i, attr := range attrs {
+		args[i] = attr
+	}
+	return args
+}
+
+// config returns the configuration the entry is emitted with, or the defaults for an entry built outside a Logger.
+func (e Entry) config() *conf {
+	if e.c == nil {
+		return &conf{}
+	}
+	return e.c
+}
@@ -0,0 +1,99 @@
+package slogger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEntryHooks(t *testing.T) {
+	tests := []struct {
+		name          string
+		entry         logEntry
+		wantLog       []string
+		wantDropped   bool
+		wantAfterHook bool
+	}{
+		{
+			name:          "RealtimeTagged",
+			entry:         logEntry{statusCode: 500, count: 1, realtimeDetails: realtimeDetails{path: "/api/pay"}},
+			wantLog:       []string{"level=ERROR", "msg=\"payment failed\"", "team=payments"},
+			wantAfterHook: true,
+		},
+		{
+			name:        "RealtimeDropped",
+			entry:       logEntry{statusCode: 200, count: 1, realtimeDetails: realtimeDetails{path: "/healthz"}},
+			wantDropped: true,
+		},
+		{
+			name:          "AggregateTagged",
+			entry:         logEntry{statusCode: 200, count: 3, isAggregate: true, aggregatePath: "/api/pay", aggregateDetails: aggregateDetails{sumLatency: 3 * time.Millisecond}},
+			wantLog:       []string{"level=INFO", "counter=3", "team=payments"},
+			wantAfterHook: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var after []Entry
+			c := configure(
+				WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+				WithOnEntry(func(e *Entry) {
+					if e.Path() == "/healthz" {
+						e.Drop()
+					}
+				}),
+				WithOnEntry(func(e *Entry) {
+					if strings.HasPrefix(e.Path(), "/api/pay") || strings.HasPrefix(e.AggregatePath(), "/api/pay") {
+						e.SetTag("team", "payments")
+					}
+					if e.StatusCode() >= 500 {
+						e.SetLevel(slog.LevelError)
+						e.SetMessage("payment failed")
+					}
+				}),
+				WithAfterEntry(func(e Entry) {
+					after = append(after, e)
+				}),
+			)
+
+			printLog("test", test.entry, c)
+
+			if test.wantDropped && buf.Len() > 0 {
+				t.Errorf("expected entry to be dropped, got %s", buf.String())
+			}
+			for _, s := range test.wantLog {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("expected %q in log output: %s", s, buf.String())
+				}
+			}
+			if test.wantAfterHook {
+				if len(after) != 1 {
+					t.Fatalf("expected 1 after hook call, got %d", len(after))
+				}
+				if after[0].Tags()["team"] != "payments" {
+					t.Errorf("expected tag in after hook entry, got %v", after[0].Tags())
+				}
+			} else if len(after) != 0 {
+				t.Errorf("expected no after hook call, got %d", len(after))
+			}
+		})
+	}
+}
+
+func TestEntrySetTagCopiesTags(t *testing.T) {
+	var e Entry
+	e.SetTag("a", "1")
+	copied := e
+	copied.SetTag("b", "2")
+
+	if _, ok := e.Tags()["b"]; ok {
+		t.Error("expected SetTag on a copy not to modify the original entry")
+	}
+	if len(copied.Tags()) != 2 {
+		t.Errorf("expected 2 tags on the copy, got %v", copied.Tags())
+	}
+}
@@ -0,0 +1,409 @@
+package slogger
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is the layout of the timestamp in rotated file names, eg: access-2024-01-02T15-04-05.000.log.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// defaultFileBufferSize is the number of writes buffered by a FileSink when FileBufferSize is not used.
+const defaultFileBufferSize = 1000
+
+// ErrFileSinkClosed is returned by FileSink.Flush after the sink is closed.
+var ErrFileSinkClosed = errors.New("file sink is closed")
+
+// FileOption configures a FileSink.
+type FileOption func(f *FileSink)
+
+// FileMaxSize rotates the file before a write would make it larger than size bytes.
+func FileMaxSize(size int64) FileOption {
+	return func(f *FileSink) {
+		f.maxSize = size
+	}
+}
+
+// FileRotateInterval rotates the file at every multiple of interval, eg: time.Hour rotates at the start of each hour.
+func FileRotateInterval(interval time.Duration) FileOption {
+	return func(f *FileSink) {
+		f.interval = interval
+	}
+}
+
+// FileCompress gzips the rotated files.
+func FileCompress(enabled bool) FileOption {
+	return func(f *FileSink) {
+		f.compress = enabled
+	}
+}
+
+// FileMaxBackups keeps at most n rotated files. Zero keeps all of them.
+func FileMaxBackups(n int) FileOption {
+	return func(f *FileSink) {
+		f.maxBackups = n
+	}
+}
+
+// FileMaxAge removes the rotated files older than age. Zero keeps all of them.
+func FileMaxAge(age time.Duration) FileOption {
+	return func(f *FileSink) {
+		f.maxAge = age
+	}
+}
+
+// FileReopenOnSIGHUP reopens the file when the process receives SIGHUP, so that it can be rotated by logrotate.
+func FileReopenOnSIGHUP(enabled bool) FileOption {
+	return func(f *FileSink) {
+		f.reopenOnSIGHUP = enabled
+	}
+}
+
+// FileBufferSize sets the number of writes buffered before they are written to disk. Writes are dropped when the buffer is full.
+func FileBufferSize(size int) FileOption {
+	return func(f *FileSink) {
+		f.bufferSize = size
+	}
+}
+
+// FileFormat writes entries as access log lines using an Apache style format string, eg: CombinedLogFormat.
+// By default entries are written as JSON lines with the attributes of the Logger schema.
+func FileFormat(format string) FileOption {
+	return func(f *FileSink) {
+		f.format = format
+	}
+}
+
+// FileSink is a Sink writing entries to a file, rotating it by size or interval.
+// Writes go through a bounded buffer to a dedicated goroutine, so a slow disk does not block the caller.
+// FileSink is also an io.Writer, eg: to be used with slog.NewJSONHandler and WithLogger.
+type FileSink struct {
+	path           string
+	maxSize        int64
+	interval       time.Duration
+	compress       bool
+	maxBackups     int
+	maxAge         time.Duration
+	reopenOnSIGHUP bool
+	bufferSize     int
+	format         string
+	now            TimeSource
+
+	encoder Sink
+	queue   chan fileWrite
+	hup     chan os.Signal
+	closing chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+
+	// The fields below are owned by the writer goroutine.
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+	mill         sync.WaitGroup
+	millMu       sync.Mutex
+}
+
+// fileWrite is either data to write or a flush request, closed once the previous writes are done.
+type fileWrite struct {
+	data    []byte
+	flushed chan struct{}
+}
+
+// NewFileSink opens or creates the file at path and starts the writer goroutine. Close must be called to release it.
+func NewFileSink(path string, opts ...FileOption) (*FileSink, error) {
+	f := &FileSink{
+		path:       path,
+		bufferSize: defaultFileBufferSize,
+		now:        time.Now,
+		closing:    make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	if f.format != "" {
+		a, err := NewAccessLogWriter(f, f.format)
+		if err != nil {
+			return nil, err
+		}
+		f.encoder = a
+	} else {
+		f.encoder = NewJSONSink(f)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	f.queue = make(chan fileWrite, f.bufferSize)
+	if f.reopenOnSIGHUP {
+		f.hup = make(chan os.Signal, 1)
+		signal.Notify(f.hup, syscall.SIGHUP)
+	}
+	go f.run()
+	return f, nil
+}
+
+// Emit writes an entry in the configured format.
+func (f *FileSink) Emit(e Entry) error {
+	return f.encoder.Emit(e)
+}
+
+// Write buffers a copy of p. It never blocks: when the buffer is full or the sink is closed, p is dropped and counted.
+func (f *FileSink) Write(p []byte) (int, error) {
+	select {
+	case <-f.closing:
+		f.dropped.Add(1)
+		return len(p), nil
+	default:
+	}
+	select {
+	case f.queue <- fileWrite{data: slices.Clone(p)}:
+	default:
+		f.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Flush waits until the writes buffered so far are written to the file.
+func (f *FileSink) Flush() error {
+	select {
+	case <-f.done:
+		return ErrFileSinkClosed
+	default:
+	}
+	flushed := make(chan struct{})
+	select {
+	case f.queue <- fileWrite{flushed: flushed}:
+	case <-f.done:
+		return ErrFileSinkClosed
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-f.done:
+		return nil
+	}
+}
+
+// Close writes the buffered writes, closes the file and waits for the pending compressions.
+func (f *FileSink) Close() error {
+	f.once.Do(func() {
+		if f.hup != nil {
+			signal.Stop(f.hup)
+		}
+		close(f.closing)
+	})
+	<-f.done
+	f.mill.Wait()
+	return nil
+}
+
+// DroppedCount returns the number of writes dropped because the buffer was full.
+func (f *FileSink) DroppedCount() uint64 {
+	return f.dropped.Load()
+}
+
+// run writes the buffered data until the sink is closed.
+func (f *FileSink) run() {
+	defer close(f.done)
+	for {
+		select {
+		case w := <-f.queue:
+			f.handle(w)
+		case <-f.hup:
+			f.reopen()
+		case <-f.closing:
+			for {
+				select {
+				case w := <-f.queue:
+					f.handle(w)
+				default:
+					if err := f.file.Close(); err != nil {
+						slog.Error("file sink close failed", "path", f.path, "error", err)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle writes data, rotating the file first when needed, or acknowledges a flush request.
+func (f *FileSink) handle(w fileWrite) {
+	if w.flushed != nil {
+		close(w.flushed)
+		return
+	}
+	if f.shouldRotate(len(w.data)) {
+		f.rotate()
+	}
+	n, err := f.file.Write(w.data)
+	f.size += int64(n)
+	if err != nil {
+		slog.Error("file sink write failed", "path", f.path, "error", err)
+	}
+}
+
+// shouldRotate reports whether the file must be rotated before writing n bytes.
+func (f *FileSink) shouldRotate(n int) bool {
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(n) > f.maxSize {
+		return true
+	}
+	return f.interval > 0 && !f.now().Before(f.nextRotation)
+}
+
+// open opens the file in append mode and computes the next interval rotation.
+func (f *FileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	if f.interval > 0 {
+		f.nextRotation = f.now().Truncate(f.interval).Add(f.interval)
+	}
+	return nil
+}
+
+// reopen closes and reopens the file, eg: after logrotate moved it.
+func (f *FileSink) reopen() {
+	_ = f.file.Close()
+	if err := f.open(); err != nil {
+		slog.Error("file sink reopen failed", "path", f.path, "error", err)
+	}
+}
+
+// rotate renames the file with a timestamp suffix, opens a new one and compresses and prunes the backups in the background.
+func (f *FileSink) rotate() {
+	_ = f.file.Close()
+	now := f.now()
+	ext := filepath.Ext(f.path)
+	backup := strings.TrimSuffix(f.path, ext) + "-" + now.UTC().Format(backupTimeFormat) + ext
+	if err := os.Rename(f.path, backup); err != nil {
+		slog.Error("file sink rotation failed", "path", f.path, "error", err)
+	}
+	if err := f.open(); err != nil {
+		slog.Error("file sink rotation failed", "path", f.path, "error", err)
+		return
+	}
+	if !f.compress && f.maxBackups <= 0 && f.maxAge <= 0 {
+		return
+	}
+	f.mill.Add(1)
+	go func() {
+		defer f.mill.Done()
+		f.millMu.Lock()
+		defer f.millMu.Unlock()
+		f.millBackups(now)
+	}()
+}
+
+// backup is a rotated file and the time of its rotation.
+type backup struct {
+	path    string
+	rotated time.Time
+}
+
+// millBackups compresses the rotated files and removes those exceeding the retention limits at now.
+func (f *FileSink) millBackups(now time.Time) {
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	dir := filepath.Dir(f.path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		slog.Error("file sink retention failed", "path", f.path, "error", err)
+		return
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp, compressed := strings.CutSuffix(strings.TrimPrefix(name, prefix), ext+".gz")
+		if !compressed {
+			var ok bool
+			if stamp, ok = strings.CutSuffix(stamp, ext); !ok {
+				continue
+			}
+		}
+		rotated, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		b := backup{path: filepath.Join(dir, name), rotated: rotated}
+		if f.compress && !compressed {
+			if err := compressFile(b.path); err != nil {
+				slog.Error("file sink compression failed", "path", b.path, "error", err)
+			} else {
+				b.path += ".gz"
+			}
+		}
+		backups = append(backups, b)
+	}
+
+	slices.SortFunc(backups, func(a, b backup) int {
+		return b.rotated.Compare(a.rotated)
+	})
+	for i, b := range backups {
+		if (f.maxBackups > 0 && i >= f.maxBackups) || (f.maxAge > 0 && now.Sub(b.rotated) > f.maxAge) {
+			if err := os.Remove(b.path); err != nil {
+				slog.Error("file sink retention failed", "path", b.path, "error", err)
+			}
+		}
+	}
+}
+
+// compressFile gzips path to path.gz and removes path.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,209 @@
+package slogger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeClock is a TimeSource that only moves when advanced.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestFileSink(t *testing.T, clock *fakeClock, opts ...FileOption) (*FileSink, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "access.log")
+	opts = append([]FileOption{func(f *FileSink) { f.now = clock.now }}, opts...)
+	f, err := NewFileSink(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f, path
+}
+
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(strings.TrimSuffix(path, ".log") + "-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []FileOption
+		advance  time.Duration
+		expected int
+	}{
+		{"BySize", []FileOption{FileMaxSize(10)}, time.Millisecond, 2},
+		{"ByInterval", []FileOption{FileRotateInterval(time.Hour)}, time.Hour, 2},
+		{"MaxBackups", []FileOption{FileMaxSize(10), FileMaxBackups(1)}, time.Millisecond, 1},
+		{"MaxAge", []FileOption{FileMaxSize(10), FileMaxAge(30 * time.Minute)}, time.Hour, 1},
+		{"NoRotation", nil, time.Hour, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := &fakeClock{t: time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)}
+			f, path := newTestFileSink(t, clock, test.opts...)
+
+			for _, line := range []string{"first line\n", "second line\n", "third line\n"} {
+				if _, err := f.Write([]byte(line)); err != nil {
+					t.Fatal(err)
+				}
+				if err := f.Flush(); err != nil {
+					t.Fatal(err)
+				}
+				clock.advance(test.advance)
+			}
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := backups(t, path); len(got) != test.expected {
+				t.Errorf("expected %d backups, got %v", test.expected, got)
+			}
+			current, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(string(current), "third line\n") {
+				t.Errorf("expected the last line in the current file, got %q", current)
+			}
+		})
+	}
+}
+
+func TestFileSinkCompress(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)}
+	f, path := newTestFileSink(t, clock, FileMaxSize(10), FileCompress(true))
+
+	_, _ = f.Write([]byte("first line\n"))
+	clock.advance(time.Second)
+	_, _ = f.Write([]byte("second line\n"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := backups(t, path)
+	if len(got) != 1 || !strings.HasSuffix(got[0], "access-2024-01-02T10-30-01.000.log.gz") {
+		t.Fatalf("expected a single compressed backup, got %v", got)
+	}
+	file, err := os.Open(got[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "first line\n" {
+		t.Errorf("unexpected backup content %q", content)
+	}
+}
+
+func TestFileSinkEmit(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	f, path := newTestFileSink(t, clock, FileFormat(CommonLogFormat))
+
+	if err := f.Emit(realtimeEntry()); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Emit(aggregatedEntry()); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], "10.0.0.1 - - [") {
+		t.Errorf("expected a single access log line, got %q", content)
+	}
+	if err := f.Flush(); err != ErrFileSinkClosed {
+		t.Errorf("expected ErrFileSinkClosed after close, got %v", err)
+	}
+}
+
+func TestFileSinkReopenOnSIGHUP(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	f, path := newTestFileSink(t, clock, FileReopenOnSIGHUP(true))
+
+	_, _ = f.Write([]byte("before\n"))
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("SIGHUP unavailable: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, _ = f.Write([]byte("after\n"))
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "after\n" {
+		t.Errorf("expected the reopened file to contain only the new line, got %q", content)
+	}
+}
+
+func TestFileSinkDropsWhenFull(t *testing.T) {
+	f := &FileSink{queue: make(chan fileWrite, 1), closing: make(chan struct{})}
+	for i := 0; i < 3; i++ {
+		if _, err := f.Write([]byte("line\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if f.DroppedCount() != 2 {
+		t.Errorf("expected 2 dropped writes, got %d", f.DroppedCount())
+	}
+}
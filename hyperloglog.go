@@ -0,0 +1,141 @@
+package slogger
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+const (
+	// MinHyperLogLogPrecision and MaxHyperLogLogPrecision bound the precision of a HyperLogLog.
+	MinHyperLogLogPrecision = 4
+	MaxHyperLogLogPrecision = 18
+	// DefaultHyperLogLogPrecision uses 16 KiB per sketch, for a standard error of about 0.8%.
+	DefaultHyperLogLogPrecision = 14
+
+	hyperLogLogVersion = 1
+)
+
+// ErrHyperLogLogPrecision is returned when merging sketches of different precisions.
+var ErrHyperLogLogPrecision = errors.New("slogger: HyperLogLog precisions differ")
+
+// HyperLogLog estimates the number of distinct values added to it in 2^precision bytes, with a standard error of
+// about 1.04/sqrt(2^precision). Values are hashed with a fixed function, so sketches built in different windows,
+// shards or processes with the same precision can be merged. A HyperLogLog is not safe for concurrent use.
+type HyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// NewHyperLogLog returns an empty sketch. The precision must be between MinHyperLogLogPrecision and
+// MaxHyperLogLogPrecision.
+func NewHyperLogLog(precision int) (*HyperLogLog, error) {
+	if precision < MinHyperLogLogPrecision || precision > MaxHyperLogLogPrecision {
+		return nil, fmt.Errorf("slogger: HyperLogLog precision %d out of range [%d, %d]", precision, MinHyperLogLogPrecision, MaxHyperLogLogPrecision)
+	}
+	return &HyperLogLog{precision: uint8(precision), registers: make([]uint8, 1<<precision)}, nil
+}
+
+// Precision returns the precision of the sketch.
+func (h *HyperLogLog) Precision() int {
+	return int(h.precision)
+}
+
+// Add adds a value to the sketch.
+func (h *HyperLogLog) Add(value string) {
+	x := hashString(value)
+	p := h.precision
+	idx := x >> (64 - p)
+	rank := uint8(bits.LeadingZeros64(x<<p|1<<(p-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// Estimate returns the estimated number of distinct values added.
+func (h *HyperLogLog) Estimate() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := hyperLogLogAlpha(len(h.registers)) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+// Merge adds the values of other to the sketch. Both sketches must have the same precision.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if other.precision != h.precision {
+		return ErrHyperLogLogPrecision
+	}
+	for i, r := range other.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Clone returns a copy of the sketch.
+func (h *HyperLogLog) Clone() *HyperLogLog {
+	return &HyperLogLog{precision: h.precision, registers: append([]uint8(nil), h.registers...)}
+}
+
+// MarshalBinary encodes the sketch, eg: to merge it in another process.
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	return append([]byte{hyperLogLogVersion, h.precision}, h.registers...), nil
+}
+
+// UnmarshalBinary decodes a sketch encoded with MarshalBinary.
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || data[0] != hyperLogLogVersion {
+		return errors.New("slogger: invalid HyperLogLog encoding")
+	}
+	p := data[1]
+	if p < MinHyperLogLogPrecision || p > MaxHyperLogLogPrecision || len(data) != 2+1<<p {
+		return errors.New("slogger: invalid HyperLogLog encoding")
+	}
+	h.precision = p
+	h.registers = append([]uint8(nil), data[2:]...)
+	return nil
+}
+
+// hyperLogLogAlpha is the bias correction constant for m registers.
+func hyperLogLogAlpha(m int) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(m))
+}
+
+// hashString hashes a value with a fixed function, so sketches and counters built in different processes can be merged.
+func hashString(value string) uint64 {
+	f := fnv.New64a()
+	_, _ = f.Write([]byte(value))
+	return mix64(f.Sum64())
+}
+
+// mix64 is the splitmix64 finalizer, spreading the bits of the FNV hash.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
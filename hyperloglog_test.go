@@ -0,0 +1,94 @@
+package slogger
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestHyperLogLogEstimate(t *testing.T) {
+	tests := []struct {
+		precision int
+		distinct  int
+	}{
+		{MinHyperLogLogPrecision, 10},
+		{10, 1000},
+		{DefaultHyperLogLogPrecision, 0},
+		{DefaultHyperLogLogPrecision, 1},
+		{DefaultHyperLogLogPrecision, 100},
+		{DefaultHyperLogLogPrecision, 100000},
+		{MaxHyperLogLogPrecision, 50000},
+	}
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.precision)+"/"+strconv.Itoa(test.distinct), func(t *testing.T) {
+			h, err := NewHyperLogLog(test.precision)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < test.distinct; i++ {
+				h.Add("10.0." + strconv.Itoa(i))
+				h.Add("10.0." + strconv.Itoa(i))
+			}
+			// Four standard errors.
+			tolerance := 4*1.04/math.Sqrt(float64(int(1)<<test.precision))*float64(test.distinct) + 1
+			if got := float64(h.Estimate()); math.Abs(got-float64(test.distinct)) > tolerance {
+				t.Errorf("expected about %d, got %v", test.distinct, got)
+			}
+		})
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a, _ := NewHyperLogLog(12)
+	b, _ := NewHyperLogLog(12)
+	for i := 0; i < 6000; i++ {
+		a.Add("client-" + strconv.Itoa(i))
+	}
+	for i := 3000; i < 9000; i++ {
+		b.Add("client-" + strconv.Itoa(i))
+	}
+
+	// Sketches are exchanged in their binary form, eg: between shards.
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded HyperLogLog
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Estimate() != b.Estimate() || decoded.Precision() != 12 {
+		t.Fatalf("expected the decoded sketch to match, got %d and %d", decoded.Estimate(), b.Estimate())
+	}
+
+	merged := a.Clone()
+	if err := merged.Merge(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := merged.Estimate(); math.Abs(float64(got)-9000) > 9000*0.08 {
+		t.Errorf("expected about 9000 distinct values, got %d", got)
+	}
+	if a.Estimate() == merged.Estimate() {
+		t.Error("expected Clone to copy the registers")
+	}
+
+	other, _ := NewHyperLogLog(10)
+	if err := merged.Merge(other); !errors.Is(err, ErrHyperLogLogPrecision) {
+		t.Errorf("expected ErrHyperLogLogPrecision, got %v", err)
+	}
+}
+
+func TestHyperLogLogInvalid(t *testing.T) {
+	for _, p := range []int{MinHyperLogLogPrecision - 1, MaxHyperLogLogPrecision + 1} {
+		if _, err := NewHyperLogLog(p); err == nil {
+			t.Errorf("expected an error for precision %d", p)
+		}
+	}
+	for _, data := range [][]byte{nil, {2, 4}, {hyperLogLogVersion, 4, 0}, {hyperLogLogVersion, 30}} {
+		var h HyperLogLog
+		if err := h.UnmarshalBinary(data); err == nil {
+			t.Errorf("expected an error decoding %v", data)
+		}
+	}
+}
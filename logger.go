@@ -7,52 +7,197 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Logger is a logging utility that handles real-time and aggregated logging for application events.
 // It processes log entries with optional configuration for headers, paths, and bot detection.
 type Logger struct {
-	queue chan logEntry
+	queue             chan logEntry
+	skipped           atomic.Uint64
+	ctx               context.Context
+	aggregatorOnce    sync.Once
+	inspect           chan chan window
+	aggregatorRunning atomic.Bool
+	// aggregatorDone is closed once the aggregator has emitted its last windows, or when it will never start.
+	aggregatorDone chan struct{}
+	asyncOnce      sync.Once
+	async          atomic.Pointer[asyncPipeline]
+	// updateMu serialises updates and the shutdown; stopping is set, with updateMu held, once the sinks are closing.
+	updateMu sync.Mutex
+	stopping bool
+	done     chan struct{}
 
-	conf *conf
+	conf atomic.Pointer[conf]
 }
 
 // New initializes a new Logger instance with the specified application name, version, and optional configuration options.
+// The Logger stops when ctx is done: see Done.
 func New(ctx context.Context, opts ...Option) *Logger {
+	return newLogger(ctx, configure(opts...))
+}
 
+// NewWithError is like New, but it validates the configuration first and returns a *ValidationError or *ConflictError,
+// joined with errors.Join, when settings are invalid or conflicting.
+func NewWithError(ctx context.Context, opts ...Option) (*Logger, error) {
 	logConf := configure(opts...)
+	if err := logConf.validate(); err != nil {
+		return nil, err
+	}
+	return newLogger(ctx, logConf), nil
+}
 
+// newLogger creates a Logger with the given configuration and starts its sinks and aggregator.
+func newLogger(ctx context.Context, logConf *conf) *Logger {
 	a := &Logger{
+		ctx:            ctx,
+		queue:          make(chan logEntry, max(logConf.aggregationQueueSize, 0)),
+		inspect:        make(chan chan window),
+		aggregatorDone: make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+	a.conf.Store(logConf)
+	a.start(logConf)
+	go a.shutdown()
+	return a
+}
 
-		conf: logConf,
+// Done returns a channel closed once the Logger has stopped after its context is done: the last aggregation windows
+// are emitted, the async buffer is written and every sink has delivered its entries and flushed. Entries emitted
+// afterwards are dropped and counted by SinkDroppedCount.
+func (a *Logger) Done() <-chan struct{} {
+	return a.done
+}
+
+// shutdown waits until the context is done, then stops the producers before the sinks they write to: the aggregator
+// first, then the async pipeline, and the sink workers last.
+func (a *Logger) shutdown() {
+	defer close(a.done)
+	<-a.ctx.Done()
+	a.aggregatorOnce.Do(func() {
+		close(a.aggregatorDone)
+	})
+	<-a.aggregatorDone
+	a.asyncOnce.Do(func() {})
+	if p := a.async.Load(); p != nil {
+		p.wait()
 	}
 
-	if logConf.isAggregationEnabled {
-		a.queue = make(chan logEntry, logConf.aggregationQueueSize)
-		go a.initLoggerAggregator(ctx)
+	a.updateMu.Lock()
+	a.stopping = true
+	logConf := a.config()
+	a.updateMu.Unlock()
+	logConf.stopSinks()
+}
+
+// config returns the current configuration.
+func (a *Logger) config() *conf {
+	return a.conf.Load()
+}
+
+// start starts the sinks, async pipeline and aggregator required by the configuration that are not running yet.
+// Sinks added once the Logger is stopping are not started: their entries are dropped and counted.
+func (a *Logger) start(logConf *conf) {
+	if !a.stopping {
+		logConf.startSinks(a.ctx)
 	}
-	return a
+	if logConf.async != nil {
+		a.asyncOnce.Do(func() {
+			a.async.Store(startAsync(a.ctx, logConf.async))
+		})
+	}
+	if logConf.isAggregatorRequired() {
+		a.startAggregator()
+	}
+}
+
+// startAggregator starts the aggregator, at most once per Logger.
+func (a *Logger) startAggregator() {
+	a.aggregatorOnce.Do(func() {
+		a.initLoggerAggregator(a.ctx)
+	})
 }
 
-// Middleware returns a Gin middleware handler function for request logging with optional path skipping and isAggregationEnabled.
+// Middleware returns a Gin middleware handler function for request logging with optional path skipping, aggregation and routing.
 func (a *Logger) Middleware() gin.HandlerFunc {
-	skipPaths := make(map[string]struct{})
-	for _, v := range a.conf.excludedPaths {
-		skipPaths[v] = struct{}{}
+	return a.handler()
+}
+
+// handlerClaimsKey is the Gin context key of the map holding, per Logger, the id of the innermost handler of a request.
+const handlerClaimsKey = "slogger.handlers"
+
+// handlerIDs numbers the handlers returned by Middleware and MiddlewareFor.
+var handlerIDs atomic.Uint64
+
+// claimRequest records id as the innermost handler of the Logger for the request, replacing the handlers it is nested in,
+// and returns a function reporting whether id is still the innermost handler once the request has been served.
+func (a *Logger) claimRequest(c *gin.Context, id uint64) func() bool {
+	claims, _ := c.Get(handlerClaimsKey)
+	handlers, ok := claims.(map[*Logger]uint64)
+	if !ok {
+		handlers = make(map[*Logger]uint64, 1)
+		c.Set(handlerClaimsKey, handlers)
+	}
+	handlers[a] = id
+	return func() bool {
+		return handlers[a] == id
 	}
+}
+
+// handlerState is the configuration of a handler, derived from the Logger configuration it was built from.
+type handlerState struct {
+	base      *conf
+	conf      *conf
+	skipPaths map[string]struct{}
+}
+
+// handler returns the Gin middleware handler function logging requests with the Logger configuration and the given
+// route overrides. The overrides are applied again whenever the Logger configuration is updated.
+func (a *Logger) handler(opts ...RouteOption) gin.HandlerFunc {
+	var state atomic.Pointer[handlerState]
+	current := func() *handlerState {
+		base := a.config()
+		if s := state.Load(); s != nil && s.base == base {
+			return s
+		}
+		s := &handlerState{base: base, conf: base, skipPaths: make(map[string]struct{})}
+		if len(opts) > 0 {
+			s.conf = base.withRouteOptions(opts...)
+		}
+		for _, v := range s.conf.excludedPaths {
+			s.skipPaths[v] = struct{}{}
+		}
+		state.Store(s)
+		return s
+	}
+	id := handlerIDs.Add(1)
 
 	return func(c *gin.Context) {
 		start := time.Now()
+		s := current()
+		logConf := s.conf
 
 		path := c.Request.URL.Path
-		if _, ok := skipPaths[path]; ok {
+		if _, ok := s.skipPaths[path]; ok {
 			c.Next()
+			a.countSkipped()
 			return
 		}
 
+		requestBody := captureRequestBody(c.Request, logConf.requestBodyLimit)
+		innermost := a.claimRequest(c, id)
 		c.Next()
 		end := time.Now()
+		if !innermost() {
+			// A nested handler of the same Logger, eg: MiddlewareFor under Middleware, logged the request.
+			return
+		}
+		if logConf.shouldSkip(c) {
+			a.countSkipped()
+			return
+		}
 
 		r := c.Request
 		routerPath := c.FullPath()
@@ -62,26 +207,41 @@ func (a *Logger) Middleware() gin.HandlerFunc {
 			responseBodySize = 0
 		}
 		ip := c.ClientIP()
-		var logItem = a.buildLogEntry(start, end, r, ip, statusCode, routerPath, responseBodySize)
-		if a.conf.isAggregationEnabled {
-			logItem.isAggregate = true
-			a.send(logItem)
+		var logItem = a.buildLogEntry(logConf, start, end, r, ip, statusCode, routerPath, responseBodySize)
+		logItem.requestBody = requestBody
+		if logConf.metrics != nil {
+			logConf.metrics.observe(logItem)
+		}
+		dest := logConf.destination(c, logItem)
+		if dest&DestinationAggregate != 0 {
+			aggItem := logItem
+			aggItem.isAggregate = true
+			a.send(aggItem)
+		}
+		if dest&DestinationRealtime == 0 {
+			return
+		}
+		keep, rate := logConf.sample(logItem)
+		if !keep {
 			return
 		}
-		printLog("api_logger v1", logItem, a.conf)
+		logItem.sampleRate = rate
+		a.emit(logItem, logConf)
 
 	}
 }
 
 // buildLogEntry constructs a logEntry object using request details, start-end timestamps, status code, and response attributes.
-func (a *Logger) buildLogEntry(start time.Time, end time.Time, r *http.Request, ip string, statusCode int, routerPath string, responseBodySize int) logEntry {
-	logConf := a.conf
-
+func (a *Logger) buildLogEntry(logConf *conf, start time.Time, end time.Time, r *http.Request, ip string, statusCode int, routerPath string, responseBodySize int) logEntry {
 	query := r.URL.RawQuery
 	path := r.URL.Path
-	pathAggregated := a.conf.pathMappingFunction(routerPath, path, statusCode)
+	pathAggregated := logConf.pathMappingFunction(routerPath, path, statusCode)
 
 	remoteAddress, _, _ := net.SplitHostPort(r.RemoteAddr)
+	var localAddress string
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		localAddress, _, _ = net.SplitHostPort(addr.String())
+	}
 
 	//Override ip
 	if logConf.clientIPHeaders != nil && len(logConf.clientIPHeaders) > 0 {
@@ -102,6 +262,7 @@ func (a *Logger) buildLogEntry(start time.Time, end time.Time, r *http.Request,
 	}
 	referer := r.Referer()
 	proto := r.Proto
+	isSlow := logConf.isSlow(routerPath, latency)
 
 	statsD := logEntry{
 		created:       start,
@@ -110,18 +271,24 @@ func (a *Logger) buildLogEntry(start time.Time, end time.Time, r *http.Request,
 		ua:            userAgent,
 		method:        method,
 		aggregatePath: pathAggregated,
+		route:         routerPath,
 		statusCode:    statusCode,
 		count:         1,
+		sampleRate:    1,
 		proto:         proto,
+		isSlow:        isSlow,
+		level:         logConf.level(statusCode, isSlow),
 
 		isAggregate: false,
 		realtimeDetails: realtimeDetails{
 			queryString:      query,
+			localIp:          localAddress,
 			path:             path,
 			headers:          nil,
 			referer:          referer,
 			latency:          latency,
 			responseBodySize: responseBodySize,
+			accessHeaders:    captureHeaders(r.Header, logConf.accessLogHeaders()),
 		},
 		extraFields: make(map[string]extraFields),
 	}
@@ -132,7 +299,7 @@ func (a *Logger) buildLogEntry(start time.Time, end time.Time, r *http.Request,
 			isBot = 1
 		}
 		statsD.isBot = isBot
-
+		statsD.isBotDetectorEnabled = true
 	}
 	if logConf.logHeaders && len(r.Header) > 0 {
 		headers := make(map[string]string, len(r.Header))
@@ -160,6 +327,27 @@ func (a *Logger) buildLogEntry(start time.Time, end time.Time, r *http.Request,
 	return statsD
 }
 
+// countSkipped increments the skipped requests counter when enabled.
+func (a *Logger) countSkipped() {
+	if a.config().countSkipped {
+		a.skipped.Add(1)
+	}
+}
+
+// SkippedCount returns the number of requests excluded by skip paths and skip rules. It requires WithSkippedCounter(true).
+func (a *Logger) SkippedCount() uint64 {
+	return a.skipped.Load()
+}
+
+// SinkDroppedCount returns the number of entries dropped because a sink queue was full.
+func (a *Logger) SinkDroppedCount() uint64 {
+	var dropped uint64
+	for _, w := range a.config().sinks {
+		dropped += w.dropped.Load()
+	}
+	return dropped
+}
+
 // send attempts to send a logEntry to the loggingHandler's queue channel, emitting a warning if the queue is full.
 func (a *Logger) send(l logEntry) {
 
@@ -0,0 +1,266 @@
+package slogger
+
+import (
+	"bufio"
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// contentTypeText is the content type of the Prometheus text exposition format.
+	contentTypeText = "text/plain; version=0.0.4; charset=utf-8"
+	// contentTypeOpenMetrics is the content type of the OpenMetrics text format.
+	contentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// DefaultDurationBuckets are the default upper bounds, in seconds, of the request duration histogram.
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets are the default upper bounds, in bytes, of the response size histogram.
+var DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+
+// MetricsOption configures a Metrics instance.
+type MetricsOption func(m *Metrics)
+
+// MetricsNamespace sets a prefix for metric names, eg: "myapp" gives myapp_http_requests_total.
+func MetricsNamespace(namespace string) MetricsOption {
+	return func(m *Metrics) {
+		m.namespace = namespace
+	}
+}
+
+// MetricsDurationBuckets sets the upper bounds, in seconds, of the request duration histogram.
+func MetricsDurationBuckets(buckets ...float64) MetricsOption {
+	return func(m *Metrics) {
+		m.durationBuckets = buckets
+	}
+}
+
+// MetricsSizeBuckets sets the upper bounds, in bytes, of the response size histogram.
+func MetricsSizeBuckets(buckets ...float64) MetricsOption {
+	return func(m *Metrics) {
+		m.sizeBuckets = buckets
+	}
+}
+
+// WithMetrics feeds every logged request, before routing and sampling, into the given Metrics.
+func WithMetrics(m *Metrics) Option {
+	return func(c *conf) {
+		c.metrics = m
+	}
+}
+
+// Metrics collects request counters, latency histograms and response size histograms labelled by method,
+// aggregate path, status class and bot flag. It implements http.Handler, serving the Prometheus text format or
+// OpenMetrics when requested through the Accept header.
+type Metrics struct {
+	mu              sync.Mutex
+	namespace       string
+	durationBuckets []float64
+	sizeBuckets     []float64
+	series          map[metricLabels]*metricSeries
+}
+
+// metricLabels identifies a series.
+type metricLabels struct {
+	method        string
+	aggregatePath string
+	statusClass   string
+	isBot         string
+}
+
+// metricSeries holds the values of a series.
+type metricSeries struct {
+	requests uint64
+	duration histogram
+	size     histogram
+}
+
+// histogram holds per bucket counts, the sum and the count of the observed values.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// observe adds a value to the histogram.
+func (h *histogram) observe(bounds []float64, value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds))
+	}
+	for i, bound := range bounds {
+		if value <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// NewMetrics returns a Metrics instance using the default buckets unless overridden.
+func NewMetrics(opts ...MetricsOption) *Metrics {
+	m := &Metrics{
+		durationBuckets: DefaultDurationBuckets,
+		sizeBuckets:     DefaultSizeBuckets,
+		series:          make(map[metricLabels]*metricSeries),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.durationBuckets = slices.Sorted(slices.Values(m.durationBuckets))
+	m.sizeBuckets = slices.Sorted(slices.Values(m.sizeBuckets))
+	return m
+}
+
+// statusClass returns the class of a status code, eg: "2xx".
+func statusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
+
+// metricMethod returns the method label of a request: one of the standard HTTP methods, or "OTHER" so arbitrary
+// methods do not create unbounded series.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// observe records a realtime entry.
+func (m *Metrics) observe(v logEntry) {
+	isBot := "unknown"
+	if v.isBotDetectorEnabled {
+		isBot = strconv.FormatBool(v.isBot == 1)
+	}
+	labels := metricLabels{
+		method:        metricMethod(v.method),
+		aggregatePath: v.aggregatePath,
+		statusClass:   statusClass(v.statusCode),
+		isBot:         isBot,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.series[labels]
+	if !ok {
+		s = &metricSeries{}
+		m.series[labels] = s
+	}
+	s.requests++
+	s.duration.observe(m.durationBuckets, v.latency.Seconds())
+	s.size.observe(m.sizeBuckets, float64(v.responseBodySize))
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format, or in the OpenMetrics format when the client accepts it.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", contentTypeOpenMetrics)
+	} else {
+		w.Header().Set("Content-Type", contentTypeText)
+	}
+	bw := bufio.NewWriter(w)
+	m.write(bw, openMetrics)
+	_ = bw.Flush()
+}
+
+// write renders all series. Series are sorted so the output is stable.
+func (m *Metrics) write(w *bufio.Writer, openMetrics bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]metricLabels, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b metricLabels) int {
+		return cmp.Or(
+			cmp.Compare(a.method, b.method),
+			cmp.Compare(a.aggregatePath, b.aggregatePath),
+			cmp.Compare(a.statusClass, b.statusClass),
+			cmp.Compare(a.isBot, b.isBot),
+		)
+	})
+
+	requests := m.metricName("http_requests")
+	writeHeader(w, requests, "counter", "Total number of HTTP requests.", openMetrics)
+	for _, k := range keys {
+		w.WriteString(requests + "_total{" + k.String() + "} " + strconv.FormatUint(m.series[k].requests, 10) + "\n")
+	}
+
+	duration := m.metricName("http_request_duration_seconds")
+	writeHeader(w, duration, "histogram", "Duration of HTTP requests in seconds.", openMetrics)
+	for _, k := range keys {
+		writeHistogram(w, duration, k.String(), m.durationBuckets, m.series[k].duration)
+	}
+
+	size := m.metricName("http_response_size_bytes")
+	writeHeader(w, size, "histogram", "Size of HTTP response bodies in bytes.", openMetrics)
+	for _, k := range keys {
+		writeHistogram(w, size, k.String(), m.sizeBuckets, m.series[k].size)
+	}
+
+	if openMetrics {
+		w.WriteString("# EOF\n")
+	}
+}
+
+// metricName prefixes a metric name with the namespace.
+func (m *Metrics) metricName(name string) string {
+	if m.namespace == "" {
+		return name
+	}
+	return m.namespace + "_" + name
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric family. The Prometheus text format names counters with
+// their _total suffix, OpenMetrics without.
+func writeHeader(w *bufio.Writer, name, metricType, help string, openMetrics bool) {
+	if metricType == "counter" && !openMetrics {
+		name += "_total"
+	}
+	w.WriteString("# HELP " + name + " " + help + "\n")
+	w.WriteString("# TYPE " + name + " " + metricType + "\n")
+}
+
+// writeHistogram writes the cumulative buckets, sum and count of a histogram series.
+func writeHistogram(w *bufio.Writer, name, labels string, bounds []float64, h histogram) {
+	var cumulative uint64
+	for i, bound := range bounds {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		w.WriteString(name + "_bucket{" + labels + `,le="` + formatFloat(bound) + `"} ` + strconv.FormatUint(cumulative, 10) + "\n")
+	}
+	w.WriteString(name + "_bucket{" + labels + `,le="+Inf"} ` + strconv.FormatUint(h.count, 10) + "\n")
+	w.WriteString(name + "_sum{" + labels + "} " + formatFloat(h.sum) + "\n")
+	w.WriteString(name + "_count{" + labels + "} " + strconv.FormatUint(h.count, 10) + "\n")
+}
+
+// String renders the labels in exposition format.
+func (l metricLabels) String() string {
+	return `method="` + escapeLabelValue(l.method) +
+		`",aggregate_path="` + escapeLabelValue(l.aggregatePath) +
+		`",status_class="` + escapeLabelValue(l.statusClass) +
+		`",is_bot="` + escapeLabelValue(l.isBot) + `"`
+}
+
+// escapeLabelValue escapes backslashes, double quotes and line feeds in a label value.
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat formats a sample value or bucket bound.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,110 @@
+package slogger
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	m := NewMetrics(MetricsDurationBuckets(0.1, 1), MetricsSizeBuckets(100))
+	m.observe(logEntry{method: "GET", aggregatePath: "/api", statusCode: 200, realtimeDetails: realtimeDetails{latency: 50 * time.Millisecond, responseBodySize: 10}})
+	m.observe(logEntry{method: "GET", aggregatePath: "/api", statusCode: 201, realtimeDetails: realtimeDetails{latency: 500 * time.Millisecond, responseBodySize: 1000}})
+	m.observe(logEntry{method: "POST", aggregatePath: `/a"b`, statusCode: 503, isBotDetectorEnabled: true, isBot: 1, realtimeDetails: realtimeDetails{latency: 2 * time.Second}})
+
+	expected := `# HELP http_requests_total Total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown"} 2
+http_requests_total{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true"} 1
+# HELP http_request_duration_seconds Duration of HTTP requests in seconds.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown",le="0.1"} 1
+http_request_duration_seconds_bucket{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown",le="1"} 2
+http_request_duration_seconds_bucket{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown",le="+Inf"} 2
+http_request_duration_seconds_sum{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown"} 0.55
+http_request_duration_seconds_count{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown"} 2
+http_request_duration_seconds_bucket{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true",le="0.1"} 0
+http_request_duration_seconds_bucket{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true",le="1"} 0
+http_request_duration_seconds_bucket{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true",le="+Inf"} 1
+http_request_duration_seconds_sum{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true"} 2
+http_request_duration_seconds_count{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true"} 1
+# HELP http_response_size_bytes Size of HTTP response bodies in bytes.
+# TYPE http_response_size_bytes histogram
+http_response_size_bytes_bucket{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown",le="100"} 1
+http_response_size_bytes_bucket{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown",le="+Inf"} 2
+http_response_size_bytes_sum{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown"} 1010
+http_response_size_bytes_count{method="GET",aggregate_path="/api",status_class="2xx",is_bot="unknown"} 2
+http_response_size_bytes_bucket{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true",le="100"} 1
+http_response_size_bytes_bucket{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true",le="+Inf"} 1
+http_response_size_bytes_sum{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true"} 0
+http_response_size_bytes_count{method="POST",aggregate_path="/a\"b",status_class="5xx",is_bot="true"} 1
+`
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		expected    string
+	}{
+		{"PrometheusText", "", contentTypeText, expected},
+		{"OpenMetrics", "application/openmetrics-text; version=1.0.0", contentTypeOpenMetrics,
+			strings.Replace(strings.Replace(expected, "# HELP http_requests_total", "# HELP http_requests", 1), "# TYPE http_requests_total", "# TYPE http_requests", 1) + "# EOF\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.Header.Set("Accept", test.accept)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			if ct := rec.Header().Get("Content-Type"); ct != test.contentType {
+				t.Errorf("expected content type %q, got %q", test.contentType, ct)
+			}
+			body, _ := io.ReadAll(rec.Body)
+			if string(body) != test.expected {
+				t.Errorf("unexpected exposition:\ngot:\n%s\nwant:\n%s", body, test.expected)
+			}
+		})
+	}
+}
+
+func TestMetricsFedByMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewMetrics(MetricsNamespace("app"))
+	logger := New(context.Background(), WithDefaultOutput(false), WithMetrics(m), WithRouteSampleRatios(map[string]float64{"/ok": 0}))
+
+	r := gin.New()
+	r.Use(logger.Middleware())
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	for i := 0; i < 3; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	expected := `app_http_requests_total{method="GET",aggregate_path="/ok",status_class="2xx",is_bot="unknown"} 3`
+	if !strings.Contains(rec.Body.String(), expected) {
+		t.Errorf("expected %q in exposition, sampled out requests must still be counted:\n%s", expected, rec.Body.String())
+	}
+}
+
+func TestMetricsMethodLabel(t *testing.T) {
+	m := NewMetrics()
+	for _, method := range []string{"GET", "PURGE", "FOO1", "foo2"} {
+		m.observe(logEntry{method: method, aggregatePath: "/api", statusCode: 200})
+	}
+
+	if len(m.series) != 2 {
+		t.Errorf("expected non standard methods to share a series, got %d series", len(m.series))
+	}
+	if s, ok := m.series[metricLabels{method: "OTHER", aggregatePath: "/api", statusClass: "2xx", isBot: "unknown"}]; !ok || s.requests != 3 {
+		t.Errorf("expected 3 requests with method OTHER, got %+v", s)
+	}
+}
@@ -0,0 +1,697 @@
+package slogger
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// otlpScopeName is the instrumentation scope reported with every export.
+	otlpScopeName = "github.com/logocomune/gin-logger"
+	// otlpDeltaTemporality is AGGREGATION_TEMPORALITY_DELTA: each window reports only its own requests.
+	otlpDeltaTemporality = 1
+)
+
+// aggregateDurationBounds are the upper bounds, in seconds, of the request duration histogram of aggregated entries,
+// as recommended by the OpenTelemetry HTTP semantic conventions.
+var aggregateDurationBounds = [...]float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// aggregateSizeBounds are the upper bounds, in bytes, of the response body size histogram of aggregated entries.
+var aggregateSizeBounds = [...]float64{100, 1000, 10000, 100000, 1e6, 1e7}
+
+// bucketIndex returns the index of the first bound greater than or equal to value, or len(bounds).
+func bucketIndex(bounds []float64, value float64) int {
+	for i, bound := range bounds {
+		if value <= bound {
+			return i
+		}
+	}
+	return len(bounds)
+}
+
+// OTLPOption configures an OTLPExporter.
+type OTLPOption func(e *OTLPExporter)
+
+// OTLPHeaders sets HTTP headers sent with every export, eg: authentication.
+func OTLPHeaders(headers map[string]string) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.headers = headers
+	}
+}
+
+// OTLPGzip enables or disables gzip compression of the request bodies. It is enabled by default.
+func OTLPGzip(enabled bool) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.gzip = enabled
+	}
+}
+
+// OTLPBatchSize sets the number of log records that triggers an export. The default is 512.
+func OTLPBatchSize(size int) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.batchSize = size
+	}
+}
+
+// OTLPMaxBufferSize sets the maximum number of log records, and of metric points, kept while exports fail. Older
+// records are dropped first, and the points of a failed export that do not fit are dropped. The default is 4096.
+func OTLPMaxBufferSize(size int) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.maxBufferSize = size
+	}
+}
+
+// OTLPRetry sets the number of retries of a failed export and the initial backoff, doubled after each attempt.
+// The defaults are 3 retries and 500ms.
+func OTLPRetry(maxRetries int, backoff time.Duration) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.maxRetries = maxRetries
+		e.backoff = backoff
+	}
+}
+
+// OTLPFlushInterval sets how often buffered log records are exported when the batch is not full. The default is 5s.
+func OTLPFlushInterval(interval time.Duration) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.flushInterval = interval
+	}
+}
+
+// OTLPHTTPClient sets the HTTP client used for exports.
+func OTLPHTTPClient(client *http.Client) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.client = client
+	}
+}
+
+// OTLPExporter is a Sink exporting realtime entries as OTLP log records and aggregated windows as OTLP metrics,
+// using OTLP/HTTP with the JSON encoding. OTLP/gRPC is not supported: use the OTLP/HTTP receiver of the collector.
+// Static entries set with WithStaticLogEntries become resource attributes. Metrics are delta histograms and sums,
+// merged by method, route, status code and bot flag, whose start and end are those of the aggregation window.
+type OTLPExporter struct {
+	endpoint      string
+	headers       map[string]string
+	gzip          bool
+	batchSize     int
+	maxBufferSize int
+	maxRetries    int
+	backoff       time.Duration
+	flushInterval time.Duration
+	client        *http.Client
+
+	mu       sync.Mutex
+	resource []otlpKeyValue
+	records  []otlpLogRecord
+	points   map[otlpPointKey]*otlpPoint
+	dropped  atomic.Uint64
+	// exportMu serialises export attempts so batches are sent in order. It is not held during retry backoffs.
+	exportMu sync.Mutex
+}
+
+// NewOTLPExporter returns an OTLPExporter sending to an OTLP/HTTP endpoint, eg: "http://localhost:4318".
+// Logs are posted to endpoint/v1/logs and metrics to endpoint/v1/metrics.
+func NewOTLPExporter(endpoint string, opts ...OTLPOption) *OTLPExporter {
+	e := &OTLPExporter{
+		endpoint:      strings.TrimSuffix(endpoint, "/"),
+		gzip:          true,
+		batchSize:     512,
+		maxBufferSize: 4096,
+		maxRetries:    3,
+		backoff:       500 * time.Millisecond,
+		flushInterval: 5 * time.Second,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		points:        make(map[otlpPointKey]*otlpPoint),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// DroppedCount returns the number of log records and metric points dropped because the buffer was full.
+func (e *OTLPExporter) DroppedCount() uint64 {
+	return e.dropped.Load()
+}
+
+// bind sets the resource attributes from the static entries and exports buffered records periodically until ctx is done.
+func (e *OTLPExporter) bind(ctx context.Context, c *conf) {
+	e.mu.Lock()
+	e.resource = otlpStringAttributes(c.staticLogEntries)
+	e.mu.Unlock()
+
+	if e.flushInterval <= 0 {
+		return
+	}
+	go func() {
+		t := time.NewTicker(e.flushInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := e.flushLogs(); err != nil {
+					slog.Warn("otlp log export failed", slog.Any("error", err))
+				}
+			}
+		}
+	}()
+}
+
+// Emit buffers a realtime entry or a summary as a log record, or merges an aggregated entry into the pending metric points.
+func (e *OTLPExporter) Emit(entry Entry) error {
+	if entry.IsAggregate() && !entry.IsSummary() {
+		e.addPoint(entry)
+		return nil
+	}
+
+	record := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(entry.Time().UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       otlpSeverity(entry.Level()),
+		SeverityText:         entry.Level().String(),
+		Body:                 otlpAnyValue{StringValue: ptr(entry.Message())},
+		Attributes:           otlpAttributes(otlpEntryAttrs(entry)),
+	}
+
+	e.mu.Lock()
+	e.records = append(e.records, record)
+	e.trimLocked()
+	full := len(e.records) >= e.batchSize
+	e.mu.Unlock()
+
+	if full {
+		return e.flushLogs()
+	}
+	return nil
+}
+
+// Flush exports the buffered log records and metric points.
+func (e *OTLPExporter) Flush() error {
+	return errors.Join(e.flushLogs(), e.flushMetrics())
+}
+
+// flushLogs exports the buffered log records in batches. Records of a failed batch are put back in the buffer, so
+// they are sent first by the next attempt.
+func (e *OTLPExporter) flushLogs() error {
+	return e.withRetry(e.sendLogs)
+}
+
+// sendLogs makes a single attempt to export the buffered log records in batches. The caller must hold exportMu.
+func (e *OTLPExporter) sendLogs() (retryable bool, err error) {
+	for {
+		e.mu.Lock()
+		n := min(len(e.records), e.batchSize)
+		if n == 0 {
+			e.mu.Unlock()
+			return false, nil
+		}
+		batch := slices.Clone(e.records[:n])
+		e.records = slices.Delete(e.records, 0, n)
+		resource := e.resource
+		e.mu.Unlock()
+
+		payload := otlpLogsRequest{ResourceLogs: []otlpResourceLogs{{
+			Resource:  otlpResource{Attributes: resource},
+			ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: otlpScopeName}, LogRecords: batch}},
+		}}}
+		body, err := e.encode(payload)
+		if err != nil {
+			return false, err
+		}
+		if retryable, err := e.post("/v1/logs", body); err != nil {
+			e.mu.Lock()
+			e.records = append(batch, e.records...)
+			e.trimLocked()
+			e.mu.Unlock()
+			return retryable, err
+		}
+	}
+}
+
+// flushMetrics exports the pending metric points. Points of a failed export are merged back into the pending ones, so
+// they are sent by the next attempt.
+func (e *OTLPExporter) flushMetrics() error {
+	e.mu.Lock()
+	points := e.points
+	e.points = make(map[otlpPointKey]*otlpPoint)
+	resource := e.resource
+	e.mu.Unlock()
+	if len(points) == 0 {
+		return nil
+	}
+
+	payload := otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpResource{Attributes: resource},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: otlpScopeName}, Metrics: otlpMetricsFromPoints(points)}},
+	}}}
+	body, err := e.encode(payload)
+	if err != nil {
+		return err
+	}
+	err = e.withRetry(func() (bool, error) {
+		return e.post("/v1/metrics", body)
+	})
+	if err != nil {
+		e.mu.Lock()
+		e.restorePointsLocked(points)
+		e.mu.Unlock()
+	}
+	return err
+}
+
+// restorePointsLocked merges the points of a failed export into the pending points. Points with new attributes are
+// dropped once maxBufferSize points are pending. The caller must hold the lock.
+func (e *OTLPExporter) restorePointsLocked(points map[otlpPointKey]*otlpPoint) {
+	for key, p := range points {
+		if pending, ok := e.points[key]; ok {
+			pending.merge(p)
+			continue
+		}
+		if e.maxBufferSize > 0 && len(e.points) >= e.maxBufferSize {
+			e.dropped.Add(1)
+			continue
+		}
+		e.points[key] = p
+	}
+}
+
+// trimLocked drops the oldest log records beyond the maximum buffer size. The caller must hold the lock.
+func (e *OTLPExporter) trimLocked() {
+	if over := len(e.records) - e.maxBufferSize; e.maxBufferSize > 0 && over > 0 {
+		e.records = slices.Delete(e.records, 0, over)
+		e.dropped.Add(uint64(over))
+	}
+}
+
+// withRetry runs an export attempt under exportMu, retrying with exponential backoff on network errors and retryable
+// status codes. The lock is released during the backoff, so other exports are not blocked by a failing endpoint.
+func (e *OTLPExporter) withRetry(attempt func() (retryable bool, err error)) error {
+	backoff := e.backoff
+	for n := 0; ; n++ {
+		e.exportMu.Lock()
+		retryable, err := attempt()
+		e.exportMu.Unlock()
+		if err == nil || !retryable || n >= e.maxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// encode marshals a payload to JSON, compressed with gzip when enabled.
+func (e *OTLPExporter) encode(payload any) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil || !e.gzip {
+		return body, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// post sends a single request and reports whether a failure can be retried.
+func (e *OTLPExporter) post(path string, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, e.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+		return true, fmt.Errorf("otlp export to %s: %s", path, resp.Status)
+	default:
+		return false, fmt.Errorf("otlp export to %s: %s", path, resp.Status)
+	}
+}
+
+// otlpPointKey identifies the attributes of a metric data point. resolution keeps the windows of the rollup intervals
+// apart from the base windows; it is zero unless rollups are configured.
+type otlpPointKey struct {
+	method     string
+	route      string
+	statusCode int
+	isBot      string
+	resolution time.Duration
+}
+
+// otlpPoint accumulates aggregated entries sharing the same attributes, from the start of their first window to the
+// end of their last one.
+type otlpPoint struct {
+	start       time.Time
+	end         time.Time
+	count       int
+	slowCount   int
+	sumLatency  time.Duration
+	minLatency  time.Duration
+	maxLatency  time.Duration
+	sumRespSize int
+	slowEnabled bool
+	// durationCounts and sizeCounts are the histogram bucket counts.
+	durationCounts [len(aggregateDurationBounds) + 1]int
+	sizeCounts     [len(aggregateSizeBounds) + 1]int
+}
+
+// addPoint merges an aggregated entry into the pending metric points.
+func (e *OTLPExporter) addPoint(entry Entry) {
+	isBot, detected := entry.IsBot()
+	key := otlpPointKey{method: entry.Method(), route: entry.AggregatePath(), statusCode: entry.StatusCode()}
+	if detected {
+		key.isBot = strconv.FormatBool(isBot)
+	}
+	if entry.e.rollup || entry.config().isMultiResolution() {
+		key.resolution = entry.Resolution()
+	}
+
+	// Entries built outside the aggregator have no window: their creation time is used instead.
+	start, end := entry.e.windowStart, entry.e.windowStart.Add(entry.e.resolution)
+	if start.IsZero() {
+		start, end = entry.Time(), time.Now()
+	}
+
+	p := &otlpPoint{
+		start:          start,
+		end:            end,
+		count:          entry.Count(),
+		slowCount:      entry.SlowCount(),
+		sumLatency:     entry.SumLatency(),
+		minLatency:     entry.MinLatency(),
+		maxLatency:     entry.MaxLatency(),
+		sumRespSize:    entry.ResponseSize(),
+		slowEnabled:    entry.c != nil && entry.c.isSlowDetectionEnabled(),
+		durationCounts: entry.e.durationCounts,
+		sizeCounts:     entry.e.sizeCounts,
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if pending, ok := e.points[key]; ok {
+		pending.merge(p)
+		return
+	}
+	e.points[key] = p
+}
+
+// merge adds the entries accumulated by q to p.
+func (p *otlpPoint) merge(q *otlpPoint) {
+	if q.start.Before(p.start) {
+		p.start = q.start
+	}
+	if q.end.After(p.end) {
+		p.end = q.end
+	}
+	p.count += q.count
+	p.slowCount += q.slowCount
+	p.sumLatency += q.sumLatency
+	p.minLatency = min(p.minLatency, q.minLatency)
+	p.maxLatency = max(p.maxLatency, q.maxLatency)
+	p.sumRespSize += q.sumRespSize
+	p.slowEnabled = p.slowEnabled || q.slowEnabled
+	for i, n := range q.durationCounts {
+		p.durationCounts[i] += n
+	}
+	for i, n := range q.sizeCounts {
+		p.sizeCounts[i] += n
+	}
+}
+
+// otlpBuckets returns the bucket counts and bounds of a histogram data point. Entries built outside the aggregator
+// have no bucket counts, in which case the point only carries its count and sum.
+func otlpBuckets(counts []int, bounds []float64, total int) ([]string, []float64) {
+	sum := 0
+	buckets := make([]string, len(counts))
+	for i, n := range counts {
+		sum += n
+		buckets[i] = strconv.Itoa(n)
+	}
+	if sum != total {
+		return nil, nil
+	}
+	return buckets, bounds
+}
+
+// otlpMetricsFromPoints converts the pending points into request count, duration and body size metrics.
+func otlpMetricsFromPoints(points map[otlpPointKey]*otlpPoint) []otlpMetric {
+	keys := slices.SortedFunc(maps.Keys(points), func(a, b otlpPointKey) int {
+		return cmp.Or(cmp.Compare(a.method, b.method), cmp.Compare(a.route, b.route), cmp.Compare(a.statusCode, b.statusCode), cmp.Compare(a.isBot, b.isBot), cmp.Compare(a.resolution, b.resolution))
+	})
+
+	var requests, slow []otlpNumberDataPoint
+	var durations, sizes []otlpHistogramDataPoint
+	for _, k := range keys {
+		p := points[k]
+		attrs := []otlpKeyValue{
+			otlpString("http.request.method", k.method),
+			otlpString("http.route", k.route),
+			otlpInt("http.response.status_code", int64(k.statusCode)),
+		}
+		if k.isBot == "true" {
+			attrs = append(attrs, otlpString("user_agent.synthetic.type", "bot"))
+		}
+		if k.resolution > 0 {
+			attrs = append(attrs, otlpKeyValue{Key: "http.server.window.resolution", Value: otlpAnyValue{DoubleValue: ptr(k.resolution.Seconds())}})
+		}
+		start := strconv.FormatInt(p.start.UnixNano(), 10)
+		end := strconv.FormatInt(p.end.UnixNano(), 10)
+		count := strconv.Itoa(p.count)
+
+		requests = append(requests, otlpNumberDataPoint{Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end, AsInt: count})
+		if p.slowEnabled {
+			slow = append(slow, otlpNumberDataPoint{Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end, AsInt: strconv.Itoa(p.slowCount)})
+		}
+		durationBuckets, durationBounds := otlpBuckets(p.durationCounts[:], aggregateDurationBounds[:], p.count)
+		durations = append(durations, otlpHistogramDataPoint{
+			Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end,
+			Count: count, Sum: p.sumLatency.Seconds(), Min: ptr(p.minLatency.Seconds()), Max: ptr(p.maxLatency.Seconds()),
+			BucketCounts: durationBuckets, ExplicitBounds: durationBounds,
+		})
+		sizeBuckets, sizeBounds := otlpBuckets(p.sizeCounts[:], aggregateSizeBounds[:], p.count)
+		sizes = append(sizes, otlpHistogramDataPoint{
+			Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: end,
+			Count: count, Sum: float64(p.sumRespSize),
+			BucketCounts: sizeBuckets, ExplicitBounds: sizeBounds,
+		})
+	}
+
+	metrics := []otlpMetric{
+		{Name: "http.server.request.count", Unit: "{request}", Sum: &otlpSum{DataPoints: requests, AggregationTemporality: otlpDeltaTemporality, IsMonotonic: true}},
+		{Name: "http.server.request.duration", Unit: "s", Histogram: &otlpHistogram{DataPoints: durations, AggregationTemporality: otlpDeltaTemporality}},
+		{Name: "http.server.response.body.size", Unit: "By", Histogram: &otlpHistogram{DataPoints: sizes, AggregationTemporality: otlpDeltaTemporality}},
+	}
+	if len(slow) > 0 {
+		metrics = append(metrics, otlpMetric{Name: "http.server.request.slow.count", Unit: "{request}", Sum: &otlpSum{DataPoints: slow, AggregationTemporality: otlpDeltaTemporality, IsMonotonic: true}})
+	}
+	return metrics
+}
+
+// otlpEntryAttrs returns the attributes of a log record: the OpenTelemetry semantic conventions, named headers and tags.
+// Static entries are sent as resource attributes instead.
+func otlpEntryAttrs(entry Entry) []slog.Attr {
+	args := otelSchema{}.Attrs(entry)
+	for key, value := range entry.Fields() {
+		args = append(args, slog.String(key, value))
+	}
+	for key, value := range entry.e.tags {
+		args = append(args, slog.String(key, value))
+	}
+	return args
+}
+
+// otlpSeverity maps a slog level to an OTLP severity number: DEBUG=5, INFO=9, WARN=13, ERROR=17.
+func otlpSeverity(level slog.Level) int {
+	return min(max(int(level)+9, 1), 24)
+}
+
+// otlpAttributes converts slog attributes to OTLP key/values.
+func otlpAttributes(attrs []slog.Attr) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kvs = append(kvs, otlpKeyValue{Key: attr.Key, Value: otlpValue(attr.Value)})
+	}
+	return kvs
+}
+
+// otlpValue converts a slog value to an OTLP AnyValue.
+func otlpValue(v slog.Value) otlpAnyValue {
+	switch v.Kind() {
+	case slog.KindString:
+		return otlpAnyValue{StringValue: ptr(v.String())}
+	case slog.KindInt64:
+		return otlpAnyValue{IntValue: ptr(strconv.FormatInt(v.Int64(), 10))}
+	case slog.KindUint64:
+		return otlpAnyValue{IntValue: ptr(strconv.FormatUint(v.Uint64(), 10))}
+	case slog.KindFloat64:
+		return otlpAnyValue{DoubleValue: ptr(v.Float64())}
+	case slog.KindBool:
+		return otlpAnyValue{BoolValue: ptr(v.Bool())}
+	case slog.KindDuration:
+		return otlpAnyValue{IntValue: ptr(strconv.FormatInt(int64(v.Duration()), 10))}
+	case slog.KindGroup:
+		return otlpAnyValue{KvlistValue: &otlpKeyValueList{Values: otlpAttributes(v.Group())}}
+	}
+	if m, ok := v.Any().(map[string]string); ok {
+		return otlpAnyValue{KvlistValue: &otlpKeyValueList{Values: otlpStringAttributes(m)}}
+	}
+	return otlpAnyValue{StringValue: ptr(v.String())}
+}
+
+// otlpStringAttributes converts a string map to OTLP key/values sorted by key.
+func otlpStringAttributes(m map[string]string) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(m))
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		kvs = append(kvs, otlpString(k, m[k]))
+	}
+	return kvs
+}
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: ptr(value)}}
+}
+
+func otlpInt(key string, value int64) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: ptr(strconv.FormatInt(value, 10))}}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// OTLP/HTTP JSON payloads. 64-bit integers are encoded as decimal strings, as required by the OTLP JSON encoding.
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name      string         `json:"name"`
+	Unit      string         `json:"unit,omitempty"`
+	Sum       *otlpSum       `json:"sum,omitempty"`
+	Histogram *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsInt             string         `json:"asInt"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	Min               *float64       `json:"min,omitempty"`
+	Max               *float64       `json:"max,omitempty"`
+	BucketCounts      []string       `json:"bucketCounts,omitempty"`
+	ExplicitBounds    []float64      `json:"explicitBounds,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpKeyValueList struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string           `json:"stringValue,omitempty"`
+	BoolValue   *bool             `json:"boolValue,omitempty"`
+	IntValue    *string           `json:"intValue,omitempty"`
+	DoubleValue *float64          `json:"doubleValue,omitempty"`
+	KvlistValue *otlpKeyValueList `json:"kvlistValue,omitempty"`
+}
@@ -0,0 +1,269 @@
+package slogger
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// otlpReceiver is an in-process stand-in for an OTLP/HTTP collector.
+type otlpReceiver struct {
+	mu       sync.Mutex
+	failures int
+	logs     []otlpLogsRequest
+	metrics  []otlpMetricsRequest
+	headers  []http.Header
+}
+
+func (r *otlpReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.headers = append(r.headers, req.Header.Clone())
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = zr
+	}
+	switch req.URL.Path {
+	case "/v1/logs":
+		var payload otlpLogsRequest
+		if err := json.NewDecoder(body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.logs = append(r.logs, payload)
+	case "/v1/metrics":
+		var payload otlpMetricsRequest
+		if err := json.NewDecoder(body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.metrics = append(r.metrics, payload)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func findAttr(kvs []otlpKeyValue, key string) (otlpAnyValue, bool) {
+	for _, kv := range kvs {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return otlpAnyValue{}, false
+}
+
+func TestOTLPExporter(t *testing.T) {
+	receiver := &otlpReceiver{failures: 1}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	exporter := NewOTLPExporter(srv.URL, OTLPRetry(2, time.Millisecond), OTLPHeaders(map[string]string{"Authorization": "Bearer token"}), OTLPFlushInterval(0))
+	c := configure(WithStaticLogEntries(map[string]string{"service.name": "shop"}))
+	exporter.bind(context.Background(), c)
+
+	realtime := logEntry{method: "GET", route: "/api/users/:id", statusCode: 500, level: slog.LevelError, realtimeDetails: realtimeDetails{path: "/api/users/1", latency: 50 * time.Millisecond}}
+	if err := exporter.Emit(newEntry("api_logger v1", realtime, c)); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := New(ctx, WithTimeAggregation(time.Minute), WithDefaultOutput(false))
+	windowStart := time.Date(2025, time.September, 11, 3, 34, 0, 0, time.UTC)
+	w := logger.newWindowState(windowStart)
+	for i, latency := range []time.Duration{5 * time.Millisecond, 30 * time.Millisecond, 6 * time.Millisecond, 30 * time.Millisecond} {
+		ip := []string{"10.0.0.1", "10.0.0.2"}[i%2]
+		logger.aggregate(w, logEntry{ip: ip, method: "GET", aggregatePath: "/api", statusCode: 200,
+			realtimeDetails: realtimeDetails{latency: latency, responseBodySize: []int{50, 50, 500, 5000}[i]}})
+	}
+	for _, aggregated := range w.entries {
+		aggregated.windowStart, aggregated.resolution = windowStart, time.Minute
+		if err := exporter.Emit(newEntry("api_logger v1", aggregated, c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := exporter.Flush(); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+
+	if len(receiver.logs) != 1 || len(receiver.metrics) != 1 {
+		t.Fatalf("expected 1 logs and 1 metrics export, got %d and %d", len(receiver.logs), len(receiver.metrics))
+	}
+	if len(receiver.headers) != 3 {
+		t.Errorf("expected a retry after the first failure, got %d requests", len(receiver.headers))
+	}
+	if receiver.headers[0].Get("Authorization") != "Bearer token" || receiver.headers[0].Get("Content-Encoding") != "gzip" {
+		t.Errorf("unexpected request headers %v", receiver.headers[0])
+	}
+
+	rl := receiver.logs[0].ResourceLogs[0]
+	if v, ok := findAttr(rl.Resource.Attributes, "service.name"); !ok || *v.StringValue != "shop" {
+		t.Errorf("expected service.name resource attribute, got %v", rl.Resource.Attributes)
+	}
+	record := rl.ScopeLogs[0].LogRecords[0]
+	if record.SeverityNumber != 17 || record.SeverityText != "ERROR" {
+		t.Errorf("expected error severity, got %d %s", record.SeverityNumber, record.SeverityText)
+	}
+	if v, ok := findAttr(record.Attributes, "http.route"); !ok || *v.StringValue != "/api/users/:id" {
+		t.Errorf("expected http.route attribute, got %v", record.Attributes)
+	}
+	if v, ok := findAttr(record.Attributes, "http.response.status_code"); !ok || *v.IntValue != "500" {
+		t.Errorf("expected status code attribute, got %v", record.Attributes)
+	}
+
+	metrics := receiver.metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 3 {
+		t.Fatalf("expected 3 metrics, got %d", len(metrics))
+	}
+	requests := metrics[0].Sum.DataPoints
+	if len(requests) != 1 || requests[0].AsInt != "4" {
+		t.Errorf("expected merged request count of 4, got %+v", requests)
+	}
+	duration := metrics[1].Histogram.DataPoints[0]
+	if duration.Count != "4" || *duration.Min != 0.005 || *duration.Max != 0.03 {
+		t.Errorf("unexpected duration point %+v", duration)
+	}
+	if start, end := strconv.FormatInt(windowStart.UnixNano(), 10), strconv.FormatInt(windowStart.Add(time.Minute).UnixNano(), 10); duration.StartTimeUnixNano != start || duration.TimeUnixNano != end {
+		t.Errorf("expected the window %s-%s, got %s-%s", start, end, duration.StartTimeUnixNano, duration.TimeUnixNano)
+	}
+	if expected := []string{"1", "1", "0", "2", "0", "0", "0", "0", "0", "0", "0", "0", "0", "0", "0"}; !slices.Equal(duration.BucketCounts, expected) || len(duration.ExplicitBounds) != len(expected)-1 {
+		t.Errorf("unexpected duration buckets %v with bounds %v", duration.BucketCounts, duration.ExplicitBounds)
+	}
+	size := metrics[2].Histogram.DataPoints[0]
+	if expected := []string{"2", "1", "1", "0", "0", "0", "0"}; !slices.Equal(size.BucketCounts, expected) || !slices.Equal(size.ExplicitBounds, aggregateSizeBounds[:]) {
+		t.Errorf("unexpected size buckets %v with bounds %v", size.BucketCounts, size.ExplicitBounds)
+	}
+}
+
+func TestOTLPExporterBoundedBuffer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	exporter := NewOTLPExporter(srv.URL, OTLPRetry(0, 0), OTLPBatchSize(2), OTLPMaxBufferSize(3), OTLPGzip(false))
+	for i := 0; i < 5; i++ {
+		_ = exporter.Emit(newEntry("test", logEntry{statusCode: 200}, nil))
+	}
+
+	exporter.mu.Lock()
+	buffered := len(exporter.records)
+	exporter.mu.Unlock()
+	if buffered > 3 {
+		t.Errorf("expected at most 3 buffered records, got %d", buffered)
+	}
+	if exporter.DroppedCount() == 0 {
+		t.Error("expected dropped records while the receiver is unavailable")
+	}
+}
+
+func TestOTLPExporterBackoffDoesNotBlockExports(t *testing.T) {
+	receiver := &otlpReceiver{failures: 1}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	backoff := 500 * time.Millisecond
+	exporter := NewOTLPExporter(srv.URL, OTLPRetry(1, backoff), OTLPFlushInterval(0))
+	c := configure()
+	exporter.bind(context.Background(), c)
+	aggregated := logEntry{isAggregate: true, method: "GET", aggregatePath: "/api", statusCode: 200, count: 1, created: time.Now()}
+	_ = exporter.Emit(newEntry("api_logger v1", aggregated, c))
+	_ = exporter.Emit(newEntry("api_logger v1", logEntry{method: "GET", statusCode: 200}, c))
+
+	// The metrics export fails once and waits for the backoff before retrying.
+	metricsDone := make(chan error, 1)
+	go func() { metricsDone <- exporter.flushMetrics() }()
+	deadline := time.Now().Add(time.Second)
+	for {
+		receiver.mu.Lock()
+		requests := len(receiver.headers)
+		receiver.mu.Unlock()
+		if requests > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if err := exporter.flushLogs(); err != nil {
+		t.Fatalf("log export failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= backoff {
+		t.Errorf("expected the log export not to wait for the metrics backoff, took %v", elapsed)
+	}
+	if err := <-metricsDone; err != nil {
+		t.Errorf("expected the metrics export to succeed after a retry, got %v", err)
+	}
+}
+
+func TestOTLPExporterKeepsMetricsOfFailedExports(t *testing.T) {
+	receiver := &otlpReceiver{failures: 1}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	exporter := NewOTLPExporter(srv.URL, OTLPRetry(0, 0), OTLPMaxBufferSize(2), OTLPGzip(false))
+	c := configure()
+	aggregated := func(route string, count int) Entry {
+		return newEntry("api_logger v1", logEntry{isAggregate: true, method: "GET", aggregatePath: route, statusCode: 200, count: count, created: time.Now()}, c)
+	}
+	_ = exporter.Emit(aggregated("/a", 2))
+	if err := exporter.flushMetrics(); err == nil {
+		t.Fatal("expected the export to fail")
+	}
+
+	// The failed points are merged with the next window, up to the buffer size.
+	_ = exporter.Emit(aggregated("/a", 3))
+	_ = exporter.Emit(aggregated("/b", 1))
+	exporter.mu.Lock()
+	exporter.restorePointsLocked(map[otlpPointKey]*otlpPoint{{method: "GET", route: "/c", statusCode: 200}: {count: 1}})
+	exporter.mu.Unlock()
+	if got := exporter.DroppedCount(); got != 1 {
+		t.Errorf("expected 1 point dropped beyond the buffer size, got %d", got)
+	}
+	if err := exporter.flushMetrics(); err != nil {
+		t.Fatal(err)
+	}
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	if len(receiver.metrics) != 1 {
+		t.Fatalf("expected 1 metrics export, got %d", len(receiver.metrics))
+	}
+	requests := map[string]string{}
+	for _, m := range receiver.metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		if m.Name != "http.server.request.count" {
+			continue
+		}
+		for _, p := range m.Sum.DataPoints {
+			route, _ := findAttr(p.Attributes, "http.route")
+			requests[*route.StringValue] = p.AsInt
+		}
+	}
+	if requests["/a"] != "5" || requests["/b"] != "1" || len(requests) != 2 {
+		t.Errorf("expected the failed window merged into the next export, got %v", requests)
+	}
+}
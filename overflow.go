@@ -0,0 +1,74 @@
+package slogger
+
+import (
+	"log/slog"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// overflowKey is the IP and user agent of the buckets holding the requests beyond WithMaxAggregationKeys.
+const overflowKey = "__overflow__"
+
+// linearCounterBits is the size of the bitmap estimating the number of overflowed keys. It is accurate to a few percent
+// up to about five times as many distinct keys, in 2 KiB per window.
+const linearCounterBits = 1 << 14
+
+// WithMaxAggregationKeys limits the number of buckets of an aggregation window. Once the limit is reached, requests
+// with a new key are folded into an overflow bucket per method, aggregate path and status code, with the IP and user
+// agent set to "__overflow__", so memory stays bounded when a scanner cycles through IPs or user agents. The estimated
+// number of overflowed keys is reported once per window. Zero removes the limit.
+func WithMaxAggregationKeys(n int) Option {
+	return func(c *conf) {
+		c.maxAggregationKeys = n
+	}
+}
+
+// linearCounter estimates the number of distinct keys added with linear counting on a fixed size bitmap.
+type linearCounter struct {
+	bitmap [linearCounterBits / 64]uint64
+}
+
+func newLinearCounter() *linearCounter {
+	return &linearCounter{}
+}
+
+// add records a key.
+func (l *linearCounter) add(key string) {
+	h := hashString(key) % linearCounterBits
+	l.bitmap[h/64] |= 1 << (h % 64)
+}
+
+// merge adds the keys recorded by other.
+func (l *linearCounter) merge(other *linearCounter) {
+	if l == nil || other == nil {
+		return
+	}
+	for i, word := range other.bitmap {
+		l.bitmap[i] |= word
+	}
+}
+
+// estimate returns the estimated number of distinct keys added. Once the bitmap is full it returns a lower bound.
+func (l *linearCounter) estimate() int {
+	if l == nil {
+		return 0
+	}
+	set := 0
+	for _, word := range l.bitmap {
+		set += bits.OnesCount64(word)
+	}
+	zeros := float64(linearCounterBits - set)
+	if zeros == 0 {
+		zeros = 1
+	}
+	return int(math.Round(linearCounterBits * math.Log(linearCounterBits/zeros)))
+}
+
+// printOverflow emits the number of keys folded into overflow buckets during a window.
+func (a *Logger) printOverflow(w *windowState, duration time.Duration, overflowed int) {
+	a.printSummary("api_logger v1 overflow", slog.LevelWarn, w, duration,
+		slog.Int("maxKeys", w.maxKeys),
+		slog.Int("overflowedKeys", overflowed),
+	)
+}
@@ -0,0 +1,135 @@
+package slogger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxAggregationKeys(t *testing.T) {
+	tests := []struct {
+		name                   string
+		maxKeys                int
+		ips                    int
+		expectedBuckets        int
+		expectedOverflow       int
+		expectedOverflowedKeys int
+	}{
+		{"Unlimited", 0, 50, 51, 0, 0},
+		{"BelowLimit", 100, 50, 51, 0, 0},
+		{"AboveLimit", 10, 50, 12, 40, 41},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			logger := New(ctx, WithTimeAggregation(time.Hour), WithMaxAggregationKeys(test.maxKeys), WithDefaultOutput(false))
+			for i := 0; i < test.ips; i++ {
+				logger.send(logEntry{ip: "10.0.0." + strconv.Itoa(i), ua: "scanner", method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusNotFound})
+			}
+			logger.send(logEntry{ip: "10.0.0.1", ua: "scanner", method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusNotFound})
+			logger.send(logEntry{ip: "10.1.0.1", ua: "scanner", method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK})
+
+			ws := logger.Snapshot().Current
+			if ws.Requests != test.ips+2 {
+				t.Errorf("expected %d requests, got %d", test.ips+2, ws.Requests)
+			}
+			if len(ws.Buckets) != test.expectedBuckets {
+				t.Errorf("expected %d buckets, got %d", test.expectedBuckets, len(ws.Buckets))
+			}
+			if ws.OverflowedKeys != test.expectedOverflowedKeys {
+				t.Errorf("expected %d overflowed keys, got %d", test.expectedOverflowedKeys, ws.OverflowedKeys)
+			}
+			if test.expectedOverflow == 0 {
+				return
+			}
+			overflow := map[int]int{}
+			for _, b := range ws.Buckets {
+				if b.IP == overflowKey {
+					if b.UserAgent != overflowKey || b.AggregatePath != "/api" {
+						t.Errorf("unexpected overflow bucket %+v", b)
+					}
+					overflow[b.StatusCode] = b.Count
+				}
+			}
+			if overflow[http.StatusNotFound] != test.expectedOverflow || overflow[http.StatusOK] != 1 {
+				t.Errorf("expected overflow buckets per status, got %v", overflow)
+			}
+		})
+	}
+}
+
+func TestLinearCounter(t *testing.T) {
+	for _, n := range []int{1, 100, 10000, 50000} {
+		l := newLinearCounter()
+		for i := 0; i < n; i++ {
+			l.add("key-" + strconv.Itoa(i))
+			l.add("key-" + strconv.Itoa(i))
+		}
+		if got := l.estimate(); math.Abs(float64(got-n)) > 0.05*float64(n)+1 {
+			t.Errorf("expected about %d keys, got %d", n, got)
+		}
+	}
+	if got := (*linearCounter)(nil).estimate(); got != 0 {
+		t.Errorf("expected 0 for a nil counter, got %d", got)
+	}
+}
+
+func TestOverflowSummary(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var buf lockedBuffer
+	logger := New(ctx, WithTimeAggregation(time.Hour), WithMaxAggregationKeys(1), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	logger.send(logEntry{ip: "10.0.0.1", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	logger.send(logEntry{ip: "10.0.0.2", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	logger.send(logEntry{ip: "10.0.0.3", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	_ = logger.Snapshot()
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "api_logger v1 overflow") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN msg=\"api_logger v1 overflow\"") || !strings.Contains(out, "maxKeys=1 overflowedKeys=2") {
+		t.Errorf("expected an overflow summary, got %s", out)
+	}
+	if !strings.Contains(out, "ip="+overflowKey) {
+		t.Errorf("expected an overflow bucket, got %s", out)
+	}
+}
+
+func TestOverflowSummaryThroughSinks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	summaries := make(chan Entry, 10)
+	logger := New(ctx, WithTimeAggregation(time.Hour), WithMaxAggregationKeys(1), WithDefaultOutput(false),
+		WithSink(SinkFunc(func(e Entry) error {
+			if e.IsSummary() {
+				summaries <- e
+			}
+			return nil
+		})))
+	logger.send(logEntry{ip: "10.0.0.1", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	logger.send(logEntry{ip: "10.0.0.2", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	_ = logger.Snapshot()
+	// The last window is delivered to the sinks before the Logger stops.
+	cancel()
+	<-logger.Done()
+
+	select {
+	case e := <-summaries:
+		if e.Message() != "api_logger v1 overflow" || e.Level() != slog.LevelWarn || !strings.Contains(fmt.Sprint(e.Attrs()...), "overflowedKeys=1") {
+			t.Errorf("unexpected overflow summary %s %v", e.Message(), e.Attrs())
+		}
+	default:
+		t.Fatal("expected the overflow summary to reach the sink with the default output disabled")
+	}
+}
@@ -1,6 +1,7 @@
 package slogger
 
 import (
+	"context"
 	"log/slog"
 	"time"
 )
@@ -11,10 +12,14 @@ type logEntry struct {
 	ua            string
 	method        string
 	aggregatePath string
+	route         string
 
 	statusCode int
 	count      int
 	proto      string
+	isSlow     bool
+	sampleRate float64
+	level      slog.Level
 
 	isBotDetectorEnabled bool
 	isBot                int
@@ -24,6 +29,9 @@ type logEntry struct {
 	realtimeDetails
 	extraFields map[string]extraFields
 	ip          string
+	tags        map[string]string
+	// summary holds the fields of a window summary line, eg: the heavy hitters. It is nil for requests and buckets.
+	summary []slog.Attr
 }
 
 type extraFields struct {
@@ -37,72 +45,72 @@ type aggregateDetails struct {
 	maxLatency       time.Duration
 	minLatency       time.Duration
 	sumSizeRespoBody int
+	slowCount        int
+	// resolution is the interval of the window the entry was emitted for.
+	resolution time.Duration
+	// rollup is true for the entries of a rollup window.
+	rollup bool
+	// recovered is true for the buckets of a window restored from a checkpoint after it had ended.
+	recovered bool
+	// windowStart is the start of the window the entry was emitted for.
+	windowStart time.Time
+	// durationCounts and sizeCounts count the requests per bucket of aggregateDurationBounds and aggregateSizeBounds,
+	// the last bucket holding the values above the largest bound.
+	durationCounts [len(aggregateDurationBounds) + 1]int
+	sizeCounts     [len(aggregateSizeBounds) + 1]int
 	int
 }
 type realtimeDetails struct {
 	errorMessage     string
 	queryString      string
+	localIp          string
+	requestBody      string
 	path             string
 	headers          map[string]string
 	referer          string
 	latency          time.Duration
 	responseBodySize int
+	accessHeaders    map[string]string
 }
 
 // printLog processes and emits structured logging for HTTP requests, including metadata, request details, and metrics.
+// The entry goes through the OnEntry hooks, then is written to the default slog output, the access log writers and the
+// configured sinks, and finally passed to the AfterEntry hooks.
 func printLog(msg string, v logEntry, c *conf) {
-	args := []any{
-		slog.String("created", v.created.Format(time.RFC3339)),
-		slog.String("ip", v.ip),
-		slog.String("remoteIp", v.remoteIp),
-		slog.String("ua", v.ua),
-		slog.String("method", v.method),
-		slog.String("proto", v.proto),
-		slog.Int("statusCode", v.statusCode),
-		slog.Int("counter", v.count),
-	}
-	if v.referer != "" {
-		args = append(args, slog.String("referer", v.referer))
+	e := newEntry(msg, v, c)
+	for _, hook := range c.onEntryHooks {
+		hook(&e)
+		if e.dropped {
+			return
+		}
 	}
+	msg, v = e.msg, e.e
 
-	if v.isBotDetectorEnabled {
-		args = append(args, slog.Int("isBot", v.isBot))
-	}
-	if v.aggregatePath != "" {
-		args = append(args, slog.String("aggregatePath", v.aggregatePath))
+	if !c.disableDefaultOutput {
+		c.loggingHandler.LogAttrs(
+			context.Background(),
+			v.level,
+			msg,
+			logAttrs(e)...,
+		)
 	}
-	if v.isAggregate {
-		meanSizeRespBody := 0.0
-		if v.sumSizeRespoBody > 0 && v.count != 0 {
-			meanSizeRespBody = float64(v.sumSizeRespoBody) / float64(v.count)
-		}
-
-		args = append(args,
-			slog.Duration("meanLatency", v.sumLatency/time.Duration(v.count)),
-			slog.Duration("minLatency", v.minLatency),
-			slog.Duration("maxLatency", v.maxLatency),
-			slog.Float64("meanSizeRespBody", meanSizeRespBody),
-			slog.Int("sumSizeRespBody", v.sumSizeRespoBody))
-
-	} else {
-		//Only realtime
-		if v.path != "" {
-			args = append(args, slog.String("path", v.path))
-		}
-		if v.errorMessage != "" {
-			args = append(args, slog.String("errorMessage", v.errorMessage))
-		}
-		if c.logQueryString && v.queryString != "" {
-			args = append(args, slog.String("queryString", v.queryString))
-		}
-		if c.logHeaders && v.headers != nil && len(v.headers) > 0 {
-			args = append(args, slog.Any("fullHeaders", v.headers))
+	for _, w := range c.accessLogs {
+		if err := w.write(v); err != nil {
+			slog.Warn("access log write failed", slog.Any("error", err))
 		}
-		args = append(args, slog.Duration("latency", v.latency))
-
-		args = append(args, slog.Int("responseSize", v.responseBodySize))
-
 	}
+	for _, s := range c.sinks {
+		s.enqueue(e)
+	}
+	for _, hook := range c.afterEntryHooks {
+		hook(e)
+	}
+}
+
+// logAttrs returns the attributes of an entry rendered by the configured schema, followed by named headers and static entries.
+func logAttrs(e Entry) []slog.Attr {
+	v, c := e.e, e.config()
+	args := c.outputSchema().Attrs(e)
 	if c.logHeadersWithName != nil && len(c.logHeadersWithName) > 0 {
 		for key, value := range v.extraFields {
 			if value.found {
@@ -115,9 +123,8 @@ func printLog(msg string, v logEntry, c *conf) {
 			args = append(args, slog.String(key, value))
 		}
 	}
-
-	c.loggingHandler.Info(
-		msg,
-		args...,
-	)
+	for key, value := range v.tags {
+		args = append(args, slog.String(key, value))
+	}
+	return args
 }
@@ -2,11 +2,16 @@ package slogger
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 func TestPrint(t *testing.T) {
@@ -115,6 +120,41 @@ func TestPrint(t *testing.T) {
 			},
 			wantLog: " level=INFO msg=\"log with headers\" created=2025-09-11T03:34:22Z ip=\"\" remoteIp=\"\" ua=\"\" method=\"\" proto=\"\" statusCode=0 counter=1 path=/api/data queryString=\"?id=123\" fullHeaders=map[Content-Type:application/json] latency=10ms responseSize=0",
 		},
+		{
+			name: "slow realtime log entry",
+			msg:  "slow",
+			log: logEntry{
+				created: time.Date(2025, time.September, 11, 3, 34, 22, 0, time.UTC),
+				isSlow:  true,
+				realtimeDetails: realtimeDetails{
+					latency: time.Second * 2,
+				},
+				count: 1,
+			},
+			conf: conf{
+				slowThreshold: time.Second,
+			},
+			wantLog: "counter=1 latency=2s responseSize=0 slow=true",
+		},
+		{
+			name: "aggregate log entry with slow count",
+			msg:  "aggregated slow",
+			log: logEntry{
+				created:     time.Date(2025, time.September, 11, 3, 34, 22, 0, time.UTC),
+				isAggregate: true,
+				count:       4,
+				aggregateDetails: aggregateDetails{
+					sumLatency: time.Second * 4,
+					minLatency: time.Millisecond * 500,
+					maxLatency: time.Millisecond * 1500,
+					slowCount:  2,
+				},
+			},
+			conf: conf{
+				slowRouteThresholds: map[string]time.Duration{"/api": time.Second},
+			},
+			wantLog: "sumSizeRespBody=0 slowCount=2",
+		},
 	}
 
 	for _, tt := range tests {
@@ -131,3 +171,42 @@ func TestPrint(t *testing.T) {
 		})
 	}
 }
+
+func TestRealtimeIsBot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		ua       string
+		expected string
+	}{
+		{"NoBotDetector", nil, "bot-agent", ""},
+		{"BotDetectorHuman", []Option{WithBotDetector(&BD{})}, "human-agent", "isBot=0"},
+		{"BotDetectorBot", []Option{WithBotDetector(&BD{})}, "bot-agent", "isBot=1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(context.Background(), append(test.opts, WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))...)
+			r := gin.New()
+			r.Use(logger.Middleware())
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("User-Agent", test.ua)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if test.expected == "" {
+				if strings.Contains(buf.String(), "isBot") {
+					t.Errorf("expected no isBot field without a bot detector, got %s", buf.String())
+				}
+				return
+			}
+			if !strings.Contains(buf.String(), test.expected) {
+				t.Errorf("expected %q in log output: %s", test.expected, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"time"
+)
+
+// Update applies options on top of the current configuration, eg: to enable header logging or lower sampling during an
+// incident. The configuration is copied, validated and swapped atomically: requests in flight keep the configuration
+// they started with, and an invalid update is rejected with the errors of NewWithError.
+// A new aggregation interval takes effect at the next window boundary. Updates changing WithQueueSize, or WithAsync
+// settings once the async pipeline is running, are rejected with a *ValidationError since they cannot change at runtime.
+func (a *Logger) Update(opts ...Option) error {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	return a.update(a.config(), opts)
+}
+
+// update applies options to a copy of base and makes it the current configuration. The caller must hold updateMu.
+func (a *Logger) update(base *conf, opts []Option) error {
+	next := base.clone()
+	for _, opt := range opts {
+		opt(next)
+	}
+	if err := errors.Join(next.validate(), a.validateRuntime(next)); err != nil {
+		return err
+	}
+	a.start(next)
+	a.conf.Store(next)
+	return nil
+}
+
+// validateRuntime rejects the settings of next that cannot change while the Logger is running: the aggregation queue
+// is created with the Logger, and the async pipeline once an update first enables it.
+func (a *Logger) validateRuntime(next *conf) error {
+	var errs []error
+	if size := next.aggregationQueueSize; size != cap(a.queue) {
+		errs = append(errs, &ValidationError{Field: "WithQueueSize", Value: size, Reason: "cannot be changed once the Logger is running"})
+	}
+	if p := a.async.Load(); p != nil && next.async != nil && *next.async != *p.conf {
+		errs = append(errs, &ValidationError{Field: "WithAsync", Value: *next.async, Reason: "cannot be changed once the async pipeline is running"})
+	}
+	return errors.Join(errs...)
+}
+
+// clone returns a copy of the configuration whose slices can be appended to without changing c.
+// Maps are shared, since options replace them instead of modifying them.
+func (c *conf) clone() *conf {
+	next := *c
+	next.excludedPaths = slices.Clone(c.excludedPaths)
+	next.rollupIntervals = slices.Clone(c.rollupIntervals)
+	next.skipRules = slices.Clone(c.skipRules)
+	next.accessLogs = slices.Clone(c.accessLogs)
+	next.sinks = slices.Clone(c.sinks)
+	next.onEntryHooks = slices.Clone(c.onEntryHooks)
+	next.afterEntryHooks = slices.Clone(c.afterEntryHooks)
+	next.pathMappingSetBy = nil
+	return &next
+}
+
+// WatchConfigFile polls a configuration file loaded with LoadConfigFile and applies it with FromConfig whenever it
+// changes, until ctx is done. Each reload is applied on top of the configuration the Logger had when WatchConfigFile
+// was called, so removing a setting from the file reverts it. Load and validation errors are sent on the returned
+// channel, and the previous configuration is kept; errors are dropped when the channel is not read.
+func (a *Logger) WatchConfigFile(ctx context.Context, path string, every time.Duration) <-chan error {
+	errs := make(chan error, 1)
+	base := a.config()
+	report := func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+
+	var lastMod time.Time
+	reload := func() {
+		info, err := os.Stat(path)
+		if err != nil {
+			report(err)
+			return
+		}
+		if info.ModTime().Equal(lastMod) {
+			return
+		}
+		lastMod = info.ModTime()
+		cfg, err := LoadConfigFile(path)
+		if err != nil {
+			report(err)
+			return
+		}
+		opts, err := FromConfig(cfg)
+		if err != nil {
+			report(err)
+			return
+		}
+		a.updateMu.Lock()
+		defer a.updateMu.Unlock()
+		if err := a.update(base, opts); err != nil {
+			report(err)
+		}
+	}
+
+	reload()
+	go func() {
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				reload()
+			}
+		}
+	}()
+	return errs
+}
@@ -0,0 +1,218 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lockedBuffer is a bytes.Buffer safe for concurrent writes and reads.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *lockedBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
+func TestLoggerUpdate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf lockedBuffer
+	logger := New(context.Background(), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))), WithSkipRules(SkipMethods(http.MethodHead)))
+
+	r := gin.New()
+	admin := r.Group("/admin", logger.MiddlewareFor(RouteLevel(slog.LevelWarn)))
+	admin.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api := r.Group("/api", logger.Middleware())
+	api.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(target string) string {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-Test", "on")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return buf.String()
+	}
+
+	if out := request("/api/users"); strings.Contains(out, "fullHeaders") {
+		t.Fatalf("unexpected headers before the update: %s", out)
+	}
+	if err := logger.Update(WithLogHeaders(true), WithSkipRules(SkipPathPrefix("/api/internal"))); err != nil {
+		t.Fatal(err)
+	}
+	if out := request("/api/users"); !strings.Contains(out, "fullHeaders=map[x-test:on]") {
+		t.Errorf("expected headers after the update: %s", out)
+	}
+	if out := request("/admin/users"); !strings.Contains(out, "level=WARN") || !strings.Contains(out, "fullHeaders") {
+		t.Errorf("expected the route overrides on top of the updated configuration: %s", out)
+	}
+	if n := len(logger.config().skipRules); n != 2 {
+		t.Errorf("expected 2 skip rules after the update, got %d", n)
+	}
+
+	err := logger.Update(WithSampleRatio(3))
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Field != "WithSampleRatio" {
+		t.Errorf("expected a ValidationError, got %v", err)
+	}
+	if logger.config().globalSampleRatio() != 1 {
+		t.Error("expected an invalid update to keep the previous configuration")
+	}
+}
+
+func TestLoggerUpdateConcurrentRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := New(context.Background(), WithLogger(slog.New(slog.NewTextHandler(&lockedBuffer{}, nil))))
+	r := gin.New()
+	r.Use(logger.Middleware())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		if err := logger.Update(WithLogHeaders(i%2 == 0), WithSampleRatio(0.5)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestLoggerUpdateAggregationInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	windows := make(chan time.Time, 10)
+	logger := New(ctx, WithTimeAggregation(200*time.Millisecond), WithDefaultOutput(false), WithAfterEntry(func(e Entry) {
+		windows <- time.Now()
+	}))
+	updated := time.Now()
+	if err := logger.Update(WithTimeAggregation(10 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The running window keeps the previous interval; the new one applies from the next window boundary.
+	logger.send(logEntry{statusCode: http.StatusOK, method: http.MethodGet})
+	first := <-windows
+	if first.Sub(updated) < 150*time.Millisecond {
+		t.Errorf("expected the running window to keep its interval, it ended after %v", first.Sub(updated))
+	}
+	logger.send(logEntry{statusCode: http.StatusOK, method: http.MethodGet})
+	second := <-windows
+	if second.Sub(first) > 150*time.Millisecond {
+		t.Errorf("expected the next window to use the new interval, it ended after %v", second.Sub(first))
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "logger.yaml")
+	if err := os.WriteFile(path, []byte("logHeaders: true\nskipPathPrefixes: [/static]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger := New(ctx, WithSkipRules(SkipMethods(http.MethodHead)))
+	errs := logger.WatchConfigFile(ctx, path, 5*time.Millisecond)
+	if c := logger.config(); !c.logHeaders || len(c.skipRules) != 2 {
+		t.Fatalf("expected the file to be applied, got logHeaders %v and %d skip rules", c.logHeaders, len(c.skipRules))
+	}
+
+	if err := os.WriteFile(path, []byte("queueSize: -1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second))
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "queueSize") {
+			t.Errorf("unexpected error %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a validation error")
+	}
+	if !logger.config().logHeaders {
+		t.Error("expected the previous configuration to be kept")
+	}
+
+	if err := os.WriteFile(path, []byte("sampleRatio: 0.5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(path, time.Now().Add(2*time.Second), time.Now().Add(2*time.Second))
+	waitFor(t, func() bool { return logger.config().globalSampleRatio() == 0.5 })
+	if c := logger.config(); c.logHeaders || len(c.skipRules) != 1 {
+		t.Errorf("expected settings removed from the file to be reverted, got logHeaders %v and %d skip rules", c.logHeaders, len(c.skipRules))
+	}
+}
+
+func TestWatchConfigFileAggregationInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "logger.yaml")
+	if err := os.WriteFile(path, []byte("aggregationInterval: 30s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger := New(ctx, WithAggregation(true), WithDefaultOutput(false))
+	_ = logger.WatchConfigFile(ctx, path, time.Hour)
+	if c := logger.config(); !c.isAggregationEnabled || c.aggregationInterval != 30*time.Second {
+		t.Errorf("expected aggregation to stay enabled every 30s, got %v every %v", c.isAggregationEnabled, c.aggregationInterval)
+	}
+}
+
+func TestLoggerUpdateRejectsFixedSettings(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := New(ctx, WithLogger(slog.New(slog.NewTextHandler(&lockedBuffer{}, nil))), WithQueueSize(50))
+
+	var verr *ValidationError
+	if err := logger.Update(WithQueueSize(100)); !errors.As(err, &verr) || verr.Field != "WithQueueSize" {
+		t.Fatalf("expected a WithQueueSize error, got %v", err)
+	}
+	if err := logger.Update(WithQueueSize(50), WithAsync()); err != nil {
+		t.Fatalf("expected the async pipeline to start, got %v", err)
+	}
+	if err := logger.Update(WithAsync(AsyncWorkers(4))); !errors.As(err, &verr) || verr.Field != "WithAsync" {
+		t.Fatalf("expected a WithAsync error, got %v", err)
+	}
+	if logger.config().async.workers != 1 {
+		t.Errorf("expected the configuration to be unchanged, got %d workers", logger.config().async.workers)
+	}
+	if err := logger.Update(WithAsync(), WithLogHeaders(true)); err != nil {
+		t.Errorf("expected unchanged async settings to be accepted, got %v", err)
+	}
+}
@@ -1,7 +1,9 @@
 package slogger
 
 import (
+	"bytes"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net"
 	"net/http"
 	"strings"
@@ -146,3 +148,20 @@ func getPathFromContext(r *http.Request) string {
 	}
 	return ""
 }
+
+// captureRequestBody reads up to limit bytes of the request body and puts them back in front of the rest of the
+// body, so the handlers read it unchanged. It returns an empty string when the body is empty or cannot be read.
+func captureRequestBody(r *http.Request, limit int) string {
+	if limit <= 0 || r.Body == nil || r.Body == http.NoBody {
+		return ""
+	}
+	captured, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(captured), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+	return string(captured)
+}
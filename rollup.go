@@ -0,0 +1,122 @@
+package slogger
+
+import (
+	"slices"
+	"time"
+)
+
+// SinkResolutions delivers to the sink only the aggregated entries of the given resolutions, eg: the 1h rollups
+// configured with WithTimeAggregation. Without it, a sink receives the windows of the aggregation interval only.
+// Realtime entries are not affected, use SinkFilter to exclude them.
+func SinkResolutions(resolutions ...time.Duration) SinkOption {
+	return func(w *sinkWorker) {
+		w.resolutions = resolutions
+	}
+}
+
+// Resolution returns the interval of the window an aggregated entry belongs to, or zero for a realtime entry.
+func (e Entry) Resolution() time.Duration {
+	if !e.e.isAggregate {
+		return 0
+	}
+	return e.e.resolution
+}
+
+// IsRollup reports whether the entry belongs to the window of a rollup interval configured with WithTimeAggregation.
+func (e Entry) IsRollup() bool { return e.e.rollup }
+
+// isMultiResolution reports whether rollup intervals are configured, in which case aggregated lines are tagged
+// with their resolution.
+func (c *conf) isMultiResolution() bool {
+	return len(c.rollupIntervals) > 0
+}
+
+// rollup accumulates the windows of the aggregation interval into a window of a larger interval.
+type rollup struct {
+	interval time.Duration
+	elapsed  time.Duration
+	w        *windowState
+}
+
+// newRollupWindowState starts the window of a rollup.
+func (a *Logger) newRollupWindowState(start time.Time) *windowState {
+	w := a.newWindowState(start)
+	w.rollup = true
+	return w
+}
+
+// updateRollups returns the rollups for the configured intervals, keeping those already running. Rollups whose
+// interval is no longer configured are emitted.
+func (a *Logger) updateRollups(rollups []*rollup, start time.Time) []*rollup {
+	intervals := a.config().rollupIntervals
+	next := make([]*rollup, 0, len(intervals))
+	for _, interval := range intervals {
+		i := slices.IndexFunc(rollups, func(r *rollup) bool { return r.interval == interval })
+		if i < 0 {
+			next = append(next, &rollup{interval: interval, w: a.newRollupWindowState(start)})
+			continue
+		}
+		next = append(next, rollups[i])
+	}
+	for _, r := range rollups {
+		if !slices.Contains(next, r) {
+			a.printWindow(r.w, r.interval)
+		}
+	}
+	return next
+}
+
+// rollUp merges a completed window of the given duration into each rollup and emits the rollups whose interval
+// has elapsed, or all of them when flush is true.
+func (a *Logger) rollUp(rollups []*rollup, w *windowState, duration time.Duration, end time.Time, flush bool) {
+	for _, r := range rollups {
+		a.merge(r.w, w)
+		r.elapsed += duration
+		if r.elapsed >= r.interval || flush {
+			a.printWindow(r.w, r.interval)
+			r.w = a.newRollupWindowState(end)
+			r.elapsed = 0
+		}
+	}
+}
+
+// merge adds the buckets and sketches of src to dst, folding new keys into overflow buckets beyond the key limit of dst.
+func (a *Logger) merge(dst, src *windowState) {
+	for key, v := range src.entries {
+		existing, ok := dst.entries[key]
+		if !ok && v.ip != overflowKey && dst.maxKeys > 0 && dst.keys >= dst.maxKeys {
+			dst.overflowed.add(key)
+			v.ip, v.remoteIp, v.ua, v.proto = overflowKey, "", overflowKey, ""
+			v.isBotDetectorEnabled, v.isBot = false, 0
+			key = aggregationKey(v)
+			existing, ok = dst.entries[key]
+		}
+		if !ok {
+			if v.ip != overflowKey {
+				dst.keys++
+			}
+			dst.entries[key] = v
+			continue
+		}
+		existing.count += v.count
+		existing.sumLatency += v.sumLatency
+		existing.minLatency = min(existing.minLatency, v.minLatency)
+		existing.maxLatency = max(existing.maxLatency, v.maxLatency)
+		existing.sumSizeRespoBody += v.sumSizeRespoBody
+		existing.slowCount += v.slowCount
+		existing.level = max(existing.level, v.level)
+		for i, n := range v.durationCounts {
+			existing.durationCounts[i] += n
+		}
+		for i, n := range v.sizeCounts {
+			existing.sizeCounts[i] += n
+		}
+		if v.lastMod.After(existing.lastMod) {
+			existing.lastMod = v.lastMod
+		}
+		dst.entries[key] = existing
+	}
+	dst.top.merge(src.top)
+	dst.unique.merge(src.unique)
+	dst.overflowed.merge(src.overflowed)
+}
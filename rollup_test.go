@@ -0,0 +1,203 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRollups(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var base []Entry
+	rollups := make(chan Entry, 10)
+	var buf lockedBuffer
+	logger := New(ctx, WithTimeAggregation(20*time.Millisecond, 60*time.Millisecond),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+		WithSink(SinkFunc(func(e Entry) error {
+			mu.Lock()
+			defer mu.Unlock()
+			base = append(base, e)
+			return nil
+		}), SinkResolutions(20*time.Millisecond)),
+		WithSink(SinkFunc(func(e Entry) error {
+			rollups <- e
+			return nil
+		}), SinkResolutions(60*time.Millisecond)))
+
+	for i := 0; i < 5; i++ {
+		logger.send(logEntry{ip: "10.0.0.1", method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK, realtimeDetails: realtimeDetails{latency: time.Duration(i+1) * time.Millisecond}})
+	}
+
+	var e Entry
+	select {
+	case e = <-rollups:
+	case <-time.After(time.Second):
+		t.Fatal("expected a rollup entry")
+	}
+	if e.Resolution() != 60*time.Millisecond || e.Count() != 5 || e.MinLatency() != time.Millisecond || e.MaxLatency() != 5*time.Millisecond {
+		t.Errorf("unexpected rollup entry: resolution %v, count %d, latency %v-%v", e.Resolution(), e.Count(), e.MinLatency(), e.MaxLatency())
+	}
+
+	mu.Lock()
+	count := 0
+	for _, b := range base {
+		if b.Resolution() != 20*time.Millisecond {
+			t.Errorf("expected only 20ms entries in the base sink, got %v", b.Resolution())
+		}
+		count += b.Count()
+	}
+	mu.Unlock()
+	if count != 5 {
+		t.Errorf("expected 5 requests in the base windows, got %d", count)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "resolution=20ms") || !strings.Contains(out, "resolution=60ms") {
+		t.Errorf("expected lines tagged with their resolution, got %s", out)
+	}
+}
+
+func TestRollupsNotDoubleCountedBySinks(t *testing.T) {
+	receiver := &otlpReceiver{}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+	pc := listenUDP(t)
+	statsd, err := NewStatsDSink(pc.LocalAddr().String(), StatsDTags(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer statsd.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := New(ctx, WithTimeAggregation(time.Minute, 10*time.Minute, time.Hour), WithDefaultOutput(false),
+		WithSink(NewOTLPExporter(srv.URL, OTLPFlushInterval(0))),
+		WithSink(statsd))
+
+	// A base window, folded into each rollup, which are emitted as they would be at the end of their interval.
+	start := time.Date(2025, time.September, 11, 3, 0, 0, 0, time.UTC)
+	w := logger.newWindowState(start)
+	for i := 0; i < 5; i++ {
+		logger.aggregate(w, logEntry{ip: "10.0.0.1", method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK})
+	}
+	rollups := logger.updateRollups(nil, start)
+	logger.printWindow(w, time.Minute)
+	logger.rollUp(rollups, w, time.Minute, start.Add(time.Minute), true)
+	if err := logger.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	receiver.mu.Lock()
+	otlpRequests := 0
+	for _, m := range receiver.metrics {
+		for _, point := range m.ResourceMetrics[0].ScopeMetrics[0].Metrics[0].Sum.DataPoints {
+			n, _ := strconv.Atoi(point.AsInt)
+			otlpRequests += n
+		}
+	}
+	receiver.mu.Unlock()
+	if otlpRequests != 5 {
+		t.Errorf("expected 5 requests exported over OTLP, got %d", otlpRequests)
+	}
+
+	statsdRequests := 0
+	for _, line := range strings.Split(readPacket(t, pc), "\n") {
+		if value, ok := strings.CutPrefix(line, "http.requests:"); ok {
+			n, _ := strconv.Atoi(strings.Split(value, "|")[0])
+			statsdRequests += n
+		}
+	}
+	if statsdRequests != 5 {
+		t.Errorf("expected 5 requests sent to StatsD, got %d", statsdRequests)
+	}
+}
+
+func TestOTLPPointsPerResolution(t *testing.T) {
+	exporter := NewOTLPExporter("http://127.0.0.1:0", OTLPFlushInterval(0))
+	c := configure(WithTimeAggregation(time.Minute, time.Hour))
+	bucket := logEntry{isAggregate: true, method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK, count: 5}
+	for _, resolution := range []time.Duration{time.Minute, time.Hour} {
+		v := bucket
+		v.resolution, v.rollup = resolution, resolution == time.Hour
+		if err := exporter.Emit(newEntry("api_logger v1", v, c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(exporter.points) != 2 {
+		t.Errorf("expected a point per resolution, got %d", len(exporter.points))
+	}
+}
+
+func TestWindowMerge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := New(ctx, WithMaxAggregationKeys(2), WithTopK(1), WithUniqueClients(MinHyperLogLogPrecision+6), WithDefaultOutput(false))
+	request := func(ip string, latency time.Duration) logEntry {
+		return logEntry{ip: ip, method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK, realtimeDetails: realtimeDetails{latency: latency}}
+	}
+
+	first := logger.newWindowState(time.Now())
+	logger.aggregate(first, request("10.0.0.1", 2*time.Millisecond))
+	logger.aggregate(first, request("10.0.0.1", 4*time.Millisecond))
+	logger.aggregate(first, request("10.0.0.2", time.Millisecond))
+	second := logger.newWindowState(time.Now())
+	logger.aggregate(second, request("10.0.0.1", 8*time.Millisecond))
+	logger.aggregate(second, request("10.0.0.3", time.Millisecond))
+	logger.aggregate(second, request("10.0.0.4", time.Millisecond))
+
+	rollup := logger.newWindowState(first.start)
+	logger.merge(rollup, first)
+	logger.merge(rollup, second)
+
+	ws := rollup.snapshot(time.Now(), time.Minute).stats()
+	if ws.Requests != 6 || len(ws.Buckets) != 3 {
+		t.Fatalf("expected 6 requests in 3 buckets, got %+v", ws)
+	}
+	if b := ws.Buckets[0]; b.IP != "10.0.0.1" || b.Count != 3 || b.MinLatency != 2*time.Millisecond || b.MaxLatency != 8*time.Millisecond || b.SumLatency != 14*time.Millisecond {
+		t.Errorf("unexpected merged bucket %+v", b)
+	}
+	if b := ws.Buckets[1]; b.IP != overflowKey || b.Count != 2 {
+		t.Errorf("expected the new keys in the overflow bucket, got %+v", b)
+	}
+	if ws.OverflowedKeys != 2 {
+		t.Errorf("expected 2 overflowed keys, got %d", ws.OverflowedKeys)
+	}
+	if len(ws.Top.IPs) != 1 || ws.Top.IPs[0] != (HeavyHitter{Key: "10.0.0.1", Count: 3}) {
+		t.Errorf("unexpected top IPs %v", ws.Top.IPs)
+	}
+	if len(ws.Routes) != 1 || ws.Routes[0].Requests != 6 || ws.Routes[0].UniqueClients != 4 {
+		t.Errorf("unexpected routes %+v", ws.Routes)
+	}
+}
+
+func TestOTelSchemaRollupWindowStart(t *testing.T) {
+	windowStart := time.Date(2025, time.September, 11, 3, 0, 0, 0, time.UTC)
+	// The bucket was created by a later window merged into the rollup.
+	e := newEntry("api_logger v1", logEntry{
+		created:          windowStart.Add(25 * time.Minute),
+		method:           http.MethodGet,
+		aggregatePath:    "/api",
+		statusCode:       http.StatusOK,
+		count:            3,
+		isAggregate:      true,
+		aggregateDetails: aggregateDetails{windowStart: windowStart, resolution: time.Hour, rollup: true},
+	}, configure(WithTimeAggregation(time.Minute, time.Hour), WithSchema(OTelSchema())))
+
+	attrs := map[string]string{}
+	for _, attr := range OTelSchema().Attrs(e) {
+		attrs[attr.Key] = attr.Value.String()
+	}
+	if attrs["http.server.window.start"] != "2025-09-11T03:00:00Z" || attrs["http.server.window.resolution"] != "3600" {
+		t.Errorf("expected the start and resolution of the rollup window, got %v", attrs)
+	}
+}
@@ -0,0 +1,97 @@
+package slogger
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteOption overrides part of the Logger configuration for a route group mounted with Logger.MiddlewareFor.
+// Settings shared by the whole Logger, such as the aggregator queue, interval and output, cannot be overridden.
+type RouteOption func(c *conf)
+
+// MiddlewareFor returns a Gin middleware handler function for a route group, applying the given overrides on top of the
+// Logger configuration. The returned handler shares the aggregator and output of the Logger. When the route group is
+// nested under Middleware or another MiddlewareFor of the same Logger, the request is logged once, by the innermost
+// handler, with its overrides.
+func (a *Logger) MiddlewareFor(opts ...RouteOption) gin.HandlerFunc {
+	if a.config().withRouteOptions(opts...).isAggregatorRequired() {
+		a.startAggregator()
+	}
+	return a.handler(opts...)
+}
+
+// withRouteOptions returns a copy of the configuration with the route overrides applied.
+func (c *conf) withRouteOptions(opts ...RouteOption) *conf {
+	routeConf := *c
+	for _, opt := range opts {
+		opt(&routeConf)
+	}
+	return &routeConf
+}
+
+// RouteDestination sends every request of the route group to the given destination, eg: DestinationAggregate for static assets.
+func RouteDestination(dest Destination) RouteOption {
+	return RouteRoutingPolicy(func(RoutingInfo) Destination {
+		return dest
+	})
+}
+
+// RouteRoutingPolicy overrides the routing policy of the route group.
+func RouteRoutingPolicy(policy RoutingPolicy) RouteOption {
+	return RouteOption(WithRoutingPolicy(policy))
+}
+
+// RouteLogHeaders overrides whether HTTP headers are logged for the route group.
+func RouteLogHeaders(log bool) RouteOption {
+	return RouteOption(WithLogHeaders(log))
+}
+
+// RouteHeaderToLogs overrides the named headers logged for the route group.
+func RouteHeaderToLogs(headerToLogs map[string][]string) RouteOption {
+	return RouteOption(WithHeaderToLogs(headerToLogs))
+}
+
+// RouteLogQueryString overrides whether the query string is logged for the route group.
+func RouteLogQueryString(log bool) RouteOption {
+	return RouteOption(WithLogQueryString(log))
+}
+
+// RouteLogRequestBody overrides the number of request body bytes logged for the route group, eg: for a payments API.
+func RouteLogRequestBody(maxBytes int) RouteOption {
+	return RouteOption(WithLogRequestBody(maxBytes))
+}
+
+// RouteSampleRatio overrides the sampling ratio of the route group: 1 logs every request and 0 logs only errors, slow
+// requests and bots, as with WithSampleRatio.
+func RouteSampleRatio(ratio float64) RouteOption {
+	return RouteOption(WithSampleRatio(ratio))
+}
+
+// RouteRateLimit overrides the rate limit of the route group with a dedicated limiter.
+func RouteRateLimit(perSecond float64, burst int, key SampleKey) RouteOption {
+	return RouteOption(WithRateLimit(perSecond, burst, key))
+}
+
+// RouteSlowThreshold overrides the slow request thresholds of the route group.
+func RouteSlowThreshold(threshold time.Duration, perRoute map[string]time.Duration) RouteOption {
+	return RouteOption(WithSlowThreshold(threshold, perRoute))
+}
+
+// RouteLevel overrides the level of log entries of the route group with a fixed level.
+func RouteLevel(level slog.Level) RouteOption {
+	return RouteOption(WithLevel(level))
+}
+
+// RouteLevelFunc overrides the function choosing the level of log entries of the route group.
+func RouteLevelFunc(levelFunc LevelFunc) RouteOption {
+	return RouteOption(WithLevelFunc(levelFunc))
+}
+
+// RouteSkipRules appends skip rules for the route group.
+func RouteSkipRules(rules ...SkipRule) RouteOption {
+	return func(c *conf) {
+		c.skipRules = append(append([]SkipRule{}, c.skipRules...), rules...)
+	}
+}
@@ -0,0 +1,198 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddlewareFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger := New(context.Background(), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	r := gin.New()
+	admin := r.Group("/admin", logger.MiddlewareFor(RouteLogHeaders(true), RouteLevel(slog.LevelWarn)))
+	admin.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	static := r.Group("/public/static", logger.MiddlewareFor(RouteSampleRatio(0)))
+	static.GET("/*file", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api := r.Group("/api", logger.Middleware())
+	api.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name        string
+		target      string
+		contains    []string
+		notContains []string
+	}{
+		{"AdminOverrides", "/admin/users", []string{"level=WARN", "fullHeaders=map[x-test:admin]"}, nil},
+		{"StaticSampledOut", "/public/static/app.js", nil, []string{"msg="}},
+		{"BaseConfiguration", "/api/users", []string{"level=INFO", "path=/api/users"}, []string{"fullHeaders"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			req.Header.Set("X-Test", "admin")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			for _, s := range test.contains {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("expected %q in log output: %s", s, buf.String())
+				}
+			}
+			for _, s := range test.notContains {
+				if strings.Contains(buf.String(), s) {
+					t.Errorf("unexpected %q in log output: %s", s, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareForNested(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf, other bytes.Buffer
+	logger := New(context.Background(), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	otherLogger := New(context.Background(), WithLogger(slog.New(slog.NewTextHandler(&other, nil))))
+
+	r := gin.New()
+	r.Use(logger.Middleware(), otherLogger.Middleware())
+	admin := r.Group("/admin", logger.MiddlewareFor(RouteLevel(slog.LevelWarn)))
+	admin.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name     string
+		target   string
+		expected string
+	}{
+		{"NestedGroup", "/admin/users", "level=WARN"},
+		{"GlobalOnly", "/api/users", "level=INFO"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			other.Reset()
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.target, nil))
+
+			if n := strings.Count(buf.String(), "msg="); n != 1 {
+				t.Fatalf("expected the request to be logged once, got %d lines: %s", n, buf.String())
+			}
+			if !strings.Contains(buf.String(), test.expected) {
+				t.Errorf("expected %q in log output: %s", test.expected, buf.String())
+			}
+			if n := strings.Count(other.String(), "msg="); n != 1 {
+				t.Errorf("expected another Logger to log the request too, got %d lines: %s", n, other.String())
+			}
+		})
+	}
+}
+
+func TestWithRouteOptionsDoesNotModifyBase(t *testing.T) {
+	base := configure(WithSkipRules(SkipMethods(http.MethodHead)))
+	routeConf := base.withRouteOptions(RouteLogHeaders(true), RouteSkipRules(SkipMethods(http.MethodOptions)))
+
+	if base.logHeaders {
+		t.Error("expected base logHeaders to stay false")
+	}
+	if len(base.skipRules) != 1 {
+		t.Errorf("expected 1 base skip rule, got %d", len(base.skipRules))
+	}
+	if !routeConf.logHeaders || len(routeConf.skipRules) != 2 {
+		t.Errorf("expected route overrides to be applied, got logHeaders %v and %d skip rules", routeConf.logHeaders, len(routeConf.skipRules))
+	}
+}
+
+func TestRouteLogRequestBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger := New(context.Background(), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	var received string
+	r := gin.New()
+	payments := r.Group("/api/payments", logger.MiddlewareFor(RouteLogRequestBody(12)))
+	payments.POST("", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		received = string(body)
+		c.Status(http.StatusCreated)
+	})
+	r.POST("/api/orders", logger.Middleware(), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	tests := []struct {
+		name     string
+		target   string
+		body     string
+		expected string
+	}{
+		{"Truncated", "/api/payments", `{"amount": 100, "currency": "EUR"}`, `requestBody="{\"amount\": 1"`},
+		{"Short", "/api/payments", `{}`, "requestBody={}"},
+		{"NotCaptured", "/api/orders", `{"id": 1}`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf.Reset()
+			received = ""
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, test.target, strings.NewReader(test.body)))
+
+			if test.target == "/api/payments" && received != test.body {
+				t.Errorf("expected the handler to read the whole body %q, got %q", test.body, received)
+			}
+			if test.expected == "" {
+				if strings.Contains(buf.String(), "requestBody") {
+					t.Errorf("unexpected request body in log output: %s", buf.String())
+				}
+				return
+			}
+			if !strings.Contains(buf.String(), test.expected) {
+				t.Errorf("expected %q in log output: %s", test.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestMiddlewareForAggregatedLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf lockedBuffer
+	logger := New(ctx, WithTimeAggregation(time.Hour), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	r := gin.New()
+	r.GET("/admin", logger.MiddlewareFor(RouteDestination(DestinationAggregate), RouteLevel(slog.LevelWarn)), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/public", logger.MiddlewareFor(RouteDestination(DestinationAggregate)), func(c *gin.Context) { c.Status(http.StatusOK) })
+	for _, target := range []string{"/admin", "/public"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	_ = logger.Snapshot()
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for strings.Count(buf.String(), "api_logger v1") < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	for _, expected := range []string{"level=WARN msg=\"api_logger v1\" ", "level=INFO msg=\"api_logger v1\" "} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %q in log output: %s", expected, buf.String())
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.Contains(line, "aggregatePath=/admin") && !strings.Contains(line, "level=WARN") {
+			t.Errorf("expected the route level for the /admin bucket, got %s", line)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package slogger
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Destination is a bit set selecting where a request entry is sent: realtime logging, aggregation or both.
+type Destination int
+
+const (
+	// DestinationNone drops the entry.
+	DestinationNone Destination = 0
+	// DestinationRealtime logs the entry immediately.
+	DestinationRealtime Destination = 1
+	// DestinationAggregate sends the entry to the aggregator.
+	DestinationAggregate Destination = 2
+	// DestinationBoth logs the entry immediately and sends it to the aggregator.
+	DestinationBoth = DestinationRealtime | DestinationAggregate
+)
+
+// RoutingInfo describes a completed request and is passed to a RoutingPolicy.
+type RoutingInfo struct {
+	Route      string
+	Path       string
+	Method     string
+	StatusCode int
+	Latency    time.Duration
+	Slow       bool
+	Context    *gin.Context
+}
+
+// RoutingPolicy decides the destination of each request entry.
+type RoutingPolicy func(info RoutingInfo) Destination
+
+// WithRoutingPolicy sets a per-request routing policy, replacing the all-or-nothing behaviour of WithAggregation.
+// Slow requests are always logged in realtime regardless of the policy.
+func WithRoutingPolicy(policy RoutingPolicy) Option {
+	return func(c *conf) {
+		c.routingPolicy = policy
+	}
+}
+
+// SplitByStatus returns a RoutingPolicy sending 1xx/2xx/3xx responses to success and 4xx/5xx responses to failure.
+func SplitByStatus(success, failure Destination) RoutingPolicy {
+	return func(info RoutingInfo) Destination {
+		if info.StatusCode >= 400 {
+			return failure
+		}
+		return success
+	}
+}
+
+// ErrorsRealtimePolicy returns a RoutingPolicy that aggregates successful requests and sends errors to both realtime and aggregation.
+func ErrorsRealtimePolicy() RoutingPolicy {
+	return SplitByStatus(DestinationAggregate, DestinationBoth)
+}
+
+// isAggregatorRequired reports whether the aggregator has to run, either because aggregation is enabled or a routing policy may use it.
+func (c *conf) isAggregatorRequired() bool {
+	return c.isAggregationEnabled || c.routingPolicy != nil
+}
+
+// destination resolves where an entry is sent, using the routing policy when present and the aggregation flag otherwise.
+func (c *conf) destination(ctx *gin.Context, e logEntry) Destination {
+	var dest Destination
+	if c.routingPolicy != nil {
+		dest = c.routingPolicy(RoutingInfo{
+			Route:      e.route,
+			Path:       e.path,
+			Method:     e.method,
+			StatusCode: e.statusCode,
+			Latency:    e.latency,
+			Slow:       e.isSlow,
+			Context:    ctx,
+		})
+	} else if c.isAggregationEnabled {
+		dest = DestinationAggregate
+	} else {
+		dest = DestinationRealtime
+	}
+	if e.isSlow {
+		dest |= DestinationRealtime
+	}
+	return dest
+}
@@ -0,0 +1,43 @@
+package slogger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDestination(t *testing.T) {
+	latencyPolicy := func(info RoutingInfo) Destination {
+		if info.Latency > 100*time.Millisecond {
+			return DestinationBoth
+		}
+		return DestinationAggregate
+	}
+
+	tests := []struct {
+		name     string
+		options  []Option
+		entry    logEntry
+		expected Destination
+	}{
+		{"DefaultRealtime", nil, logEntry{statusCode: 200}, DestinationRealtime},
+		{"AggregationEnabled", []Option{WithAggregation(true)}, logEntry{statusCode: 500}, DestinationAggregate},
+		{"AggregationSlowAlsoRealtime", []Option{WithAggregation(true)}, logEntry{statusCode: 200, isSlow: true}, DestinationBoth},
+		{"ErrorsRealtimeSuccess", []Option{WithRoutingPolicy(ErrorsRealtimePolicy())}, logEntry{statusCode: 204}, DestinationAggregate},
+		{"ErrorsRealtimeRedirect", []Option{WithRoutingPolicy(ErrorsRealtimePolicy())}, logEntry{statusCode: 302}, DestinationAggregate},
+		{"ErrorsRealtimeClientError", []Option{WithRoutingPolicy(ErrorsRealtimePolicy())}, logEntry{statusCode: 404}, DestinationBoth},
+		{"ErrorsRealtimeServerError", []Option{WithRoutingPolicy(ErrorsRealtimePolicy())}, logEntry{statusCode: 503}, DestinationBoth},
+		{"PolicyOverridesAggregationFlag", []Option{WithAggregation(true), WithRoutingPolicy(SplitByStatus(DestinationRealtime, DestinationNone))}, logEntry{statusCode: 200}, DestinationRealtime},
+		{"PolicyDropSlowStillRealtime", []Option{WithRoutingPolicy(SplitByStatus(DestinationNone, DestinationNone))}, logEntry{statusCode: 200, isSlow: true}, DestinationRealtime},
+		{"LatencyPolicyFast", []Option{WithRoutingPolicy(latencyPolicy)}, logEntry{realtimeDetails: realtimeDetails{latency: 10 * time.Millisecond}}, DestinationAggregate},
+		{"LatencyPolicySlow", []Option{WithRoutingPolicy(latencyPolicy)}, logEntry{realtimeDetails: realtimeDetails{latency: time.Second}}, DestinationBoth},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := configure(test.options...)
+			if result := c.destination(nil, test.entry); result != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package slogger
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// SampleKey selects the dimension used to partition rate limit buckets.
+type SampleKey int
+
+const (
+	// SampleByRoute keeps one token bucket per route (c.FullPath()).
+	SampleByRoute SampleKey = iota
+	// SampleByIP keeps one token bucket per client IP.
+	SampleByIP
+)
+
+// defaultMaxRateLimitKeys bounds the number of token buckets kept in memory by a rateLimiter.
+const defaultMaxRateLimitKeys = 10000
+
+// overflowRateLimitKey is the key of the bucket shared by the keys beyond maxKeys. It cannot be a route or an IP,
+// unlike "", the route of unmatched requests.
+const overflowRateLimitKey = "\x00overflow"
+
+// WithSampleRatio sets the fraction [0, 1] of realtime requests that are logged. By default every request is logged,
+// and 0 logs only errors, slow requests and bots, which are never sampled out.
+func WithSampleRatio(ratio float64) Option {
+	return func(c *conf) {
+		c.sampleRatio = &ratio
+	}
+}
+
+// WithRouteSampleRatios sets per-route sampling ratios, keyed by c.FullPath(), overriding the ratio set by WithSampleRatio.
+// As with WithSampleRatio, a route ratio of 1 logs every request and 0 logs only errors, slow requests and bots.
+func WithRouteSampleRatios(ratios map[string]float64) Option {
+	return func(c *conf) {
+		c.routeSampleRatios = ratios
+	}
+}
+
+// WithRateLimit limits realtime logging to perSecond lines with the given burst, using one token bucket per route or per client IP.
+func WithRateLimit(perSecond float64, burst int, key SampleKey) Option {
+	return func(c *conf) {
+		c.rateLimiter = newRateLimiter(perSecond, burst, key, time.Now)
+	}
+}
+
+// globalSampleRatio returns the ratio set by WithSampleRatio, or 1 when it is not set.
+func (c *conf) globalSampleRatio() float64 {
+	if c.sampleRatio == nil {
+		return 1
+	}
+	return *c.sampleRatio
+}
+
+// isSamplingEnabled reports whether any sampling rule has been configured.
+func (c *conf) isSamplingEnabled() bool {
+	return c.globalSampleRatio() < 1 || len(c.routeSampleRatios) > 0 || c.rateLimiter != nil
+}
+
+// alwaysLog reports whether an entry must never be sampled out: errors, slow requests and flagged bots.
+func alwaysLog(e logEntry) bool {
+	return e.statusCode >= 400 || e.isSlow || e.isBot == 1
+}
+
+// sample decides whether a realtime entry is logged and returns the rate it was sampled at.
+// The rate is the probability the line was kept, so each kept line stands for 1/rate requests.
+func (c *conf) sample(e logEntry) (keep bool, rate float64) {
+	if !c.isSamplingEnabled() || alwaysLog(e) {
+		return true, 1
+	}
+
+	rate = c.globalSampleRatio()
+	if r, ok := c.routeSampleRatios[e.route]; ok {
+		rate = r
+	}
+	if rate <= 0 {
+		return false, 0
+	}
+	if rate < 1 && rand.Float64() >= rate {
+		return false, rate
+	}
+
+	if c.rateLimiter != nil {
+		key := e.route
+		if c.rateLimiter.by == SampleByIP {
+			key = e.ip
+		}
+		allowed, factor := c.rateLimiter.allow(key)
+		if !allowed {
+			return false, rate
+		}
+		rate *= factor
+	}
+	return true, rate
+}
+
+// tokenBucket holds the state of a single rate limit bucket.
+type tokenBucket struct {
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+// rateLimiter is a keyed token bucket rate limiter with a bounded number of buckets.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	by      SampleKey
+	maxKeys int
+	now     TimeSource
+	buckets map[string]*tokenBucket
+}
+
+// newRateLimiter creates a rateLimiter refilling perSecond tokens per second up to burst tokens per key.
+func newRateLimiter(perSecond float64, burst int, by SampleKey, now TimeSource) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    perSecond,
+		burst:   float64(burst),
+		by:      by,
+		maxKeys: defaultMaxRateLimitKeys,
+		now:     now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow consumes a token for key. When allowed, factor is 1/(n+1) where n is the number of requests
+// dropped for the key since the previous allowed one, so the kept line can be re-weighted.
+func (r *rateLimiter) allow(key string) (allowed bool, factor float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	b, ok := r.buckets[key]
+	if !ok {
+		if len(r.buckets) >= r.maxKeys {
+			r.prune(now)
+		}
+		if len(r.buckets) >= r.maxKeys {
+			// Still full: share a single overflow bucket to keep memory bounded.
+			key = overflowRateLimitKey
+			b, ok = r.buckets[key]
+		}
+		if !ok {
+			b = &tokenBucket{tokens: r.burst, last: now}
+			r.buckets[key] = b
+		}
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * r.rate
+	if b.tokens > r.burst {
+		b.tokens = r.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		b.dropped++
+		return false, 0
+	}
+	b.tokens--
+	factor = 1 / float64(b.dropped+1)
+	b.dropped = 0
+	return true, factor
+}
+
+// prune removes buckets that have been idle long enough to refill completely.
+func (r *rateLimiter) prune(now time.Time) {
+	for k, b := range r.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*r.rate >= r.burst {
+			delete(r.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package slogger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      []Option
+		entry        logEntry
+		expectedKeep bool
+		expectedRate float64
+	}{
+		{"NoSampling", nil, logEntry{statusCode: 200}, true, 1},
+		{"ZeroRatioDropsSuccess", []Option{WithRouteSampleRatios(map[string]float64{"/api": 0})}, logEntry{statusCode: 200, route: "/api"}, false, 0},
+		{"ZeroRatioKeepsErrors", []Option{WithRouteSampleRatios(map[string]float64{"/api": 0})}, logEntry{statusCode: 500, route: "/api"}, true, 1},
+		{"ZeroRatioKeepsSlow", []Option{WithRouteSampleRatios(map[string]float64{"/api": 0})}, logEntry{statusCode: 200, route: "/api", isSlow: true}, true, 1},
+		{"ZeroRatioKeepsBots", []Option{WithRouteSampleRatios(map[string]float64{"/api": 0})}, logEntry{statusCode: 200, route: "/api", isBot: 1}, true, 1},
+		{"GlobalZeroRatioDropsSuccess", []Option{WithSampleRatio(0)}, logEntry{statusCode: 200, route: "/api"}, false, 0},
+		{"GlobalZeroRatioKeepsErrors", []Option{WithSampleRatio(0)}, logEntry{statusCode: 404, route: "/api"}, true, 1},
+		{"GlobalOneRatioKeepsAll", []Option{WithSampleRatio(1)}, logEntry{statusCode: 200, route: "/api"}, true, 1},
+		{"RouteRatioOverridesGlobal", []Option{WithSampleRatio(0.5), WithRouteSampleRatios(map[string]float64{"/api": 1})}, logEntry{statusCode: 200, route: "/api"}, true, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := configure(test.options...)
+			keep, rate := c.sample(test.entry)
+			if keep != test.expectedKeep {
+				t.Errorf("expected keep %v, got %v", test.expectedKeep, keep)
+			}
+			if keep && rate != test.expectedRate {
+				t.Errorf("expected rate %v, got %v", test.expectedRate, rate)
+			}
+		})
+	}
+}
+
+func TestSampleRatioDistribution(t *testing.T) {
+	c := configure(WithSampleRatio(0.25))
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		if keep, rate := c.sample(logEntry{statusCode: 200}); keep {
+			kept++
+			if rate != 0.25 {
+				t.Fatalf("expected rate 0.25, got %v", rate)
+			}
+		}
+	}
+	if kept < 2000 || kept > 3000 {
+		t.Errorf("expected about 2500 kept entries, got %d", kept)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, time.September, 11, 3, 34, 22, 0, time.UTC)
+	r := newRateLimiter(1, 2, SampleByIP, func() time.Time { return now })
+
+	for i, expected := range []bool{true, true, false, false} {
+		if allowed, _ := r.allow("10.0.0.1"); allowed != expected {
+			t.Fatalf("request %d: expected allowed %v, got %v", i, expected, allowed)
+		}
+	}
+	if allowed, factor := r.allow("10.0.0.2"); !allowed || factor != 1 {
+		t.Errorf("expected independent bucket for another key, got allowed %v factor %v", allowed, factor)
+	}
+
+	now = now.Add(time.Second)
+	allowed, factor := r.allow("10.0.0.1")
+	if !allowed {
+		t.Fatal("expected bucket to refill after one second")
+	}
+	if factor != 1.0/3 {
+		t.Errorf("expected factor 1/3 after two dropped requests, got %v", factor)
+	}
+}
+
+func TestRateLimiterBoundedKeys(t *testing.T) {
+	now := time.Date(2025, time.September, 11, 3, 34, 22, 0, time.UTC)
+	r := newRateLimiter(1, 1, SampleByIP, func() time.Time { return now })
+	r.maxKeys = 2
+
+	r.allow("a")
+	r.allow("b")
+	r.allow("c")
+	if len(r.buckets) > r.maxKeys+1 {
+		t.Errorf("expected at most %d buckets, got %d", r.maxKeys+1, len(r.buckets))
+	}
+	if _, ok := r.buckets[overflowRateLimitKey]; !ok {
+		t.Error("expected overflow bucket to be used when all buckets are busy")
+	}
+	if _, ok := r.buckets[""]; ok {
+		t.Error("expected the overflow bucket not to share the key of unmatched routes")
+	}
+}
+
+func TestSampleRateWithoutSampling(t *testing.T) {
+	var c conf
+	if c.isSamplingEnabled() {
+		t.Error("expected the zero configuration not to sample")
+	}
+	for _, schema := range []Schema{DefaultSchema(), ECSSchema(), OTelSchema(), GCPSchema()} {
+		c.schema = schema
+		found := false
+		for _, attr := range logAttrs(newEntry("", logEntry{statusCode: 200}, &c)) {
+			if strings.Contains(attr.Key, "sampl") {
+				found = attr.Value.Float64() == 1
+			}
+		}
+		if !found {
+			t.Errorf("expected %T to emit a sample rate of 1", schema)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package slogger
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema maps a log entry to the attributes of an output line. Use DefaultSchema, ECSSchema, OTelSchema or GCPSchema,
+// or implement it with the Entry accessors to name the fields for another backend. Named headers (WithHeaderToLogs),
+// static entries (WithStaticLogEntries) and tags are appended unchanged by every schema. The built-in schemas render
+// the window of a summary entry (Entry.IsSummary) in their own naming, followed by the summary fields unchanged.
+type Schema interface {
+	Attrs(e Entry) []slog.Attr
+}
+
+// WithSchema sets the schema used to name and shape the attributes of each log line. The default is DefaultSchema.
+func WithSchema(schema Schema) Option {
+	return func(c *conf) {
+		c.schema = schema
+	}
+}
+
+// outputSchema returns the configured schema, falling back to DefaultSchema.
+func (c *conf) outputSchema() Schema {
+	if c.schema == nil {
+		return defaultSchema{}
+	}
+	return c.schema
+}
+
+// DefaultSchema returns the original layout with camelCase keys such as statusCode, ua, remoteIp and counter.
+func DefaultSchema() Schema {
+	return defaultSchema{}
+}
+
+// ECSSchema returns a schema following the Elastic Common Schema, eg: http.response.status_code and user_agent.original.
+// Fields without an ECS equivalent are placed under labels.
+func ECSSchema() Schema {
+	return ecsSchema{}
+}
+
+// OTelSchema returns a schema following the OpenTelemetry HTTP semantic conventions, eg: http.request.method and url.path.
+func OTelSchema() Schema {
+	return otelSchema{}
+}
+
+// GCPSchema returns a schema placing the request fields in the httpRequest structure recognised by Google Cloud Logging.
+func GCPSchema() Schema {
+	return gcpSchema{}
+}
+
+// meanLatency returns the mean latency of an aggregated entry.
+func (v logEntry) meanLatency() time.Duration {
+	if v.count == 0 {
+		return 0
+	}
+	return v.sumLatency / time.Duration(v.count)
+}
+
+// meanSizeRespBody returns the mean response body size of an aggregated entry.
+func (v logEntry) meanSizeRespBody() float64 {
+	if v.sumSizeRespoBody > 0 && v.count != 0 {
+		return float64(v.sumSizeRespoBody) / float64(v.count)
+	}
+	return 0.0
+}
+
+// effectiveSampleRate returns the rate an entry was sampled at. Aggregated entries are never sampled.
+func (v logEntry) effectiveSampleRate() float64 {
+	if v.isAggregate || v.sampleRate == 0 {
+		return 1
+	}
+	return v.sampleRate
+}
+
+// protocolVersion returns the version part of an HTTP protocol string, eg: "1.1" for "HTTP/1.1".
+func protocolVersion(proto string) string {
+	return strings.TrimPrefix(proto, "HTTP/")
+}
+
+// requestURL returns the path followed by the query string when query string logging is enabled.
+func requestURL(v logEntry, c *conf) string {
+	if c.logQueryString && v.queryString != "" {
+		return v.path + "?" + v.queryString
+	}
+	return v.path
+}
+
+type defaultSchema struct{}
+
+// Attrs returns the attributes of an entry.
+func (s defaultSchema) Attrs(e Entry) []slog.Attr {
+	return s.attrs(e.e, e.config())
+}
+
+func (defaultSchema) attrs(v logEntry, c *conf) []slog.Attr {
+	if v.summary != nil {
+		return append([]slog.Attr{slog.Time("windowStart", v.windowStart), slog.Duration("interval", v.resolution)}, v.summary...)
+	}
+	args := []slog.Attr{
+		slog.String("created", v.created.Format(time.RFC3339)),
+		slog.String("ip", v.ip),
+		slog.String("remoteIp", v.remoteIp),
+		slog.String("ua", v.ua),
+		slog.String("method", v.method),
+		slog.String("proto", v.proto),
+		slog.Int("statusCode", v.statusCode),
+		slog.Int("counter", v.count),
+	}
+	if v.referer != "" {
+		args = append(args, slog.String("referer", v.referer))
+	}
+
+	if v.isBotDetectorEnabled {
+		args = append(args, slog.Int("isBot", v.isBot))
+	}
+	if v.aggregatePath != "" {
+		args = append(args, slog.String("aggregatePath", v.aggregatePath))
+	}
+	if v.isAggregate {
+		args = append(args,
+			slog.Duration("meanLatency", v.meanLatency()),
+			slog.Duration("minLatency", v.minLatency),
+			slog.Duration("maxLatency", v.maxLatency),
+			slog.Float64("meanSizeRespBody", v.meanSizeRespBody()),
+			slog.Int("sumSizeRespBody", v.sumSizeRespoBody))
+		if c.isSlowDetectionEnabled() {
+			args = append(args, slog.Int("slowCount", v.slowCount))
+		}
+		if c.isMultiResolution() {
+			args = append(args, slog.Duration("resolution", v.resolution))
+		}
+		if v.recovered {
+			args = append(args, slog.Bool("recovered", true))
+		}
+
+	} else {
+		//Only realtime
+		if v.path != "" {
+			args = append(args, slog.String("path", v.path))
+		}
+		if v.errorMessage != "" {
+			args = append(args, slog.String("errorMessage", v.errorMessage))
+		}
+		if c.logQueryString && v.queryString != "" {
+			args = append(args, slog.String("queryString", v.queryString))
+		}
+		if c.logHeaders && v.headers != nil && len(v.headers) > 0 {
+			args = append(args, slog.Any("fullHeaders", v.headers))
+		}
+		if v.requestBody != "" {
+			args = append(args, slog.String("requestBody", v.requestBody))
+		}
+		args = append(args, slog.Duration("latency", v.latency))
+
+		args = append(args, slog.Int("responseSize", v.responseBodySize))
+		if v.isSlow {
+			args = append(args, slog.Bool("slow", true))
+		}
+
+	}
+	args = append(args, slog.Float64("sampleRate", v.effectiveSampleRate()))
+	return args
+}
+
+type ecsSchema struct{}
+
+// Attrs returns the attributes of an entry.
+func (s ecsSchema) Attrs(e Entry) []slog.Attr {
+	return s.attrs(e.e, e.config())
+}
+
+func (ecsSchema) attrs(v logEntry, c *conf) []slog.Attr {
+	if v.summary != nil {
+		return append([]slog.Attr{slog.String("event.start", v.windowStart.Format(time.RFC3339)), slog.String("labels.interval", v.resolution.String())}, v.summary...)
+	}
+	args := []slog.Attr{
+		slog.String("event.start", v.created.Format(time.RFC3339)),
+		slog.String("client.ip", v.ip),
+		slog.String("source.ip", v.remoteIp),
+		slog.String("user_agent.original", v.ua),
+		slog.String("http.request.method", v.method),
+		slog.String("http.version", protocolVersion(v.proto)),
+		slog.Int("http.response.status_code", v.statusCode),
+	}
+	if v.referer != "" {
+		args = append(args, slog.String("http.request.referrer", v.referer))
+	}
+	if v.isBotDetectorEnabled {
+		args = append(args, slog.Bool("labels.is_bot", v.isBot == 1))
+	}
+	if v.aggregatePath != "" {
+		args = append(args, slog.String("labels.aggregate_path", v.aggregatePath))
+	}
+	args = append(args, slog.Float64("labels.sample_rate", v.effectiveSampleRate()))
+	if v.isAggregate {
+		args = append(args,
+			slog.Int("labels.count", v.count),
+			slog.Int64("event.duration", int64(v.meanLatency())),
+			slog.Int64("labels.min_duration", int64(v.minLatency)),
+			slog.Int64("labels.max_duration", int64(v.maxLatency)),
+			slog.Float64("labels.mean_response_body_bytes", v.meanSizeRespBody()),
+			slog.Int("labels.sum_response_body_bytes", v.sumSizeRespoBody))
+		if c.isSlowDetectionEnabled() {
+			args = append(args, slog.Int("labels.slow_count", v.slowCount))
+		}
+		if c.isMultiResolution() {
+			args = append(args, slog.String("labels.resolution", v.resolution.String()))
+		}
+		if v.recovered {
+			args = append(args, slog.Bool("labels.recovered", true))
+		}
+		return args
+	}
+
+	if v.path != "" {
+		args = append(args, slog.String("url.path", v.path))
+	}
+	if v.errorMessage != "" {
+		args = append(args, slog.String("error.message", v.errorMessage))
+	}
+	if c.logQueryString && v.queryString != "" {
+		args = append(args, slog.String("url.query", v.queryString))
+	}
+	if c.logHeaders && len(v.headers) > 0 {
+		args = append(args, slog.Any("http.request.headers", v.headers))
+	}
+	if v.requestBody != "" {
+		args = append(args, slog.String("http.request.body.content", v.requestBody))
+	}
+	args = append(args,
+		slog.Int64("event.duration", int64(v.latency)),
+		slog.Int("http.response.body.bytes", v.responseBodySize))
+	if v.isSlow {
+		args = append(args, slog.Bool("labels.slow", true))
+	}
+	return args
+}
+
+type otelSchema struct{}
+
+// Attrs returns the attributes of an entry.
+func (s otelSchema) Attrs(e Entry) []slog.Attr {
+	return s.attrs(e.e, e.config())
+}
+
+func (otelSchema) attrs(v logEntry, c *conf) []slog.Attr {
+	if v.summary != nil {
+		return append([]slog.Attr{slog.String("http.server.window.start", v.windowStart.Format(time.RFC3339)), slog.Float64("http.server.window.resolution", v.resolution.Seconds())}, v.summary...)
+	}
+	args := []slog.Attr{
+		slog.String("http.request.method", v.method),
+		slog.Int("http.response.status_code", v.statusCode),
+		slog.String("client.address", v.ip),
+		slog.String("network.peer.address", v.remoteIp),
+		slog.String("user_agent.original", v.ua),
+		slog.String("network.protocol.name", "http"),
+		slog.String("network.protocol.version", protocolVersion(v.proto)),
+	}
+	if v.route != "" {
+		args = append(args, slog.String("http.route", v.route))
+	}
+	if v.referer != "" {
+		args = append(args, slog.String("http.request.header.referer", v.referer))
+	}
+	if v.isBot == 1 {
+		args = append(args, slog.String("user_agent.synthetic.type", "bot"))
+	}
+	if v.aggregatePath != "" {
+		args = append(args, slog.String("http.aggregate_path", v.aggregatePath))
+	}
+	args = append(args, slog.Float64("sampling.rate", v.effectiveSampleRate()))
+	if v.isAggregate {
+		// Entries built outside the aggregator have no window: their creation time is used instead.
+		start := v.windowStart
+		if start.IsZero() {
+			start = v.created
+		}
+		args = append(args,
+			slog.String("http.server.window.start", start.Format(time.RFC3339)),
+			slog.Int("http.server.request.count", v.count),
+			slog.Float64("http.server.request.duration.mean", v.meanLatency().Seconds()),
+			slog.Float64("http.server.request.duration.min", v.minLatency.Seconds()),
+			slog.Float64("http.server.request.duration.max", v.maxLatency.Seconds()),
+			slog.Float64("http.response.body.size.mean", v.meanSizeRespBody()),
+			slog.Int("http.response.body.size.sum", v.sumSizeRespoBody))
+		if c.isSlowDetectionEnabled() {
+			args = append(args, slog.Int("http.server.request.slow_count", v.slowCount))
+		}
+		if c.isMultiResolution() {
+			args = append(args, slog.Float64("http.server.window.resolution", v.resolution.Seconds()))
+		}
+		if v.recovered {
+			args = append(args, slog.Bool("http.server.window.recovered", true))
+		}
+		return args
+	}
+
+	if v.path != "" {
+		args = append(args, slog.String("url.path", v.path))
+	}
+	if v.errorMessage != "" {
+		args = append(args, slog.String("error.message", v.errorMessage))
+	}
+	if c.logQueryString && v.queryString != "" {
+		args = append(args, slog.String("url.query", v.queryString))
+	}
+	if c.logHeaders {
+		for key, value := range v.headers {
+			args = append(args, slog.String("http.request.header."+key, value))
+		}
+	}
+	if v.requestBody != "" {
+		args = append(args, slog.String("http.request.body.content", v.requestBody))
+	}
+	args = append(args,
+		slog.Float64("http.server.request.duration", v.latency.Seconds()),
+		slog.Int("http.response.body.size", v.responseBodySize))
+	if v.isSlow {
+		args = append(args, slog.Bool("http.server.request.slow", true))
+	}
+	return args
+}
+
+type gcpSchema struct{}
+
+// Attrs returns the attributes of an entry.
+func (s gcpSchema) Attrs(e Entry) []slog.Attr {
+	return s.attrs(e.e, e.config())
+}
+
+func (gcpSchema) attrs(v logEntry, c *conf) []slog.Attr {
+	if v.summary != nil {
+		return append([]slog.Attr{slog.String("windowStart", v.windowStart.Format(time.RFC3339)), slog.String("interval", gcpDuration(v.resolution))}, v.summary...)
+	}
+	httpRequest := []any{
+		slog.String("requestMethod", v.method),
+		slog.Int("status", v.statusCode),
+		slog.String("userAgent", v.ua),
+		slog.String("remoteIp", v.ip),
+		slog.String("protocol", v.proto),
+	}
+	if v.referer != "" {
+		httpRequest = append(httpRequest, slog.String("referer", v.referer))
+	}
+
+	var args []slog.Attr
+	if v.isAggregate {
+		httpRequest = append(httpRequest,
+			slog.String("latency", gcpDuration(v.meanLatency())),
+			slog.String("responseSize", strconv.Itoa(int(v.meanSizeRespBody()))))
+		args = append(args,
+			slog.Group("httpRequest", httpRequest...),
+			slog.String("created", v.created.Format(time.RFC3339)),
+			slog.Int("counter", v.count),
+			slog.String("minLatency", gcpDuration(v.minLatency)),
+			slog.String("maxLatency", gcpDuration(v.maxLatency)),
+			slog.Int("sumSizeRespBody", v.sumSizeRespoBody))
+		if c.isSlowDetectionEnabled() {
+			args = append(args, slog.Int("slowCount", v.slowCount))
+		}
+		if c.isMultiResolution() {
+			args = append(args, slog.String("resolution", gcpDuration(v.resolution)))
+		}
+		if v.recovered {
+			args = append(args, slog.Bool("recovered", true))
+		}
+	} else {
+		if v.path != "" {
+			httpRequest = append(httpRequest, slog.String("requestUrl", requestURL(v, c)))
+		}
+		httpRequest = append(httpRequest,
+			slog.String("latency", gcpDuration(v.latency)),
+			slog.String("responseSize", strconv.Itoa(v.responseBodySize)))
+		args = append(args, slog.Group("httpRequest", httpRequest...))
+		if v.errorMessage != "" {
+			args = append(args, slog.String("errorMessage", v.errorMessage))
+		}
+		if c.logHeaders && len(v.headers) > 0 {
+			args = append(args, slog.Any("fullHeaders", v.headers))
+		}
+		if v.requestBody != "" {
+			args = append(args, slog.String("requestBody", v.requestBody))
+		}
+		if v.isSlow {
+			args = append(args, slog.Bool("slow", true))
+		}
+	}
+
+	if v.remoteIp != "" {
+		args = append(args, slog.String("remoteIp", v.remoteIp))
+	}
+	if v.isBotDetectorEnabled {
+		args = append(args, slog.Int("isBot", v.isBot))
+	}
+	if v.aggregatePath != "" {
+		args = append(args, slog.String("aggregatePath", v.aggregatePath))
+	}
+	args = append(args, slog.Float64("sampleRate", v.effectiveSampleRate()))
+	return args
+}
+
+// gcpDuration formats a duration as expected by Google Cloud Logging, eg: "0.050s".
+func gcpDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
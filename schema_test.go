@@ -0,0 +1,164 @@
+package slogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSchemas(t *testing.T) {
+	realtime := logEntry{
+		created:    time.Date(2025, time.September, 11, 3, 34, 22, 0, time.UTC),
+		ip:         "10.0.0.1",
+		remoteIp:   "192.168.1.1",
+		ua:         "Go-http-client",
+		method:     "GET",
+		proto:      "HTTP/1.1",
+		route:      "/api/users/:id",
+		statusCode: 200,
+		count:      1,
+		realtimeDetails: realtimeDetails{
+			path:             "/api/users/1",
+			queryString:      "a=1",
+			latency:          50 * time.Millisecond,
+			responseBodySize: 42,
+		},
+	}
+	aggregate := logEntry{
+		created:     time.Date(2025, time.September, 11, 3, 34, 22, 0, time.UTC),
+		method:      "GET",
+		statusCode:  200,
+		count:       2,
+		isAggregate: true,
+		aggregateDetails: aggregateDetails{
+			sumLatency:       100 * time.Millisecond,
+			minLatency:       40 * time.Millisecond,
+			maxLatency:       60 * time.Millisecond,
+			sumSizeRespoBody: 84,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		schema   Schema
+		entry    logEntry
+		expected map[string]any
+	}{
+		{"DefaultRealtime", DefaultSchema(), realtime, map[string]any{"statusCode": 200.0, "ua": "Go-http-client", "counter": 1.0, "path": "/api/users/1"}},
+		{"ECSRealtime", ECSSchema(), realtime, map[string]any{"http.response.status_code": 200.0, "user_agent.original": "Go-http-client", "http.version": "1.1", "url.query": "a=1", "event.duration": 50000000.0}},
+		{"ECSAggregate", ECSSchema(), aggregate, map[string]any{"labels.count": 2.0, "event.duration": 50000000.0, "labels.sum_response_body_bytes": 84.0}},
+		{"OTelRealtime", OTelSchema(), realtime, map[string]any{"http.request.method": "GET", "http.route": "/api/users/:id", "client.address": "10.0.0.1", "http.server.request.duration": 0.05, "http.response.body.size": 42.0}},
+		{"OTelAggregate", OTelSchema(), aggregate, map[string]any{"http.server.request.count": 2.0, "http.server.request.duration.max": 0.06}},
+		{"GCPRealtime", GCPSchema(), realtime, map[string]any{"httpRequest": map[string]any{
+			"requestMethod": "GET",
+			"status":        200.0,
+			"userAgent":     "Go-http-client",
+			"remoteIp":      "10.0.0.1",
+			"protocol":      "HTTP/1.1",
+			"requestUrl":    "/api/users/1?a=1",
+			"latency":       "0.05s",
+			"responseSize":  "42",
+		}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			c := configure(
+				WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+				WithLogQueryString(true),
+				WithSchema(test.schema),
+			)
+			printLog("test", test.entry, c)
+
+			var got map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON output %q: %v", buf.String(), err)
+			}
+			for key, expected := range test.expected {
+				value, ok := got[key]
+				if !ok {
+					t.Errorf("missing key %s in %s", key, buf.String())
+					continue
+				}
+				if gotJSON, _ := json.Marshal(value); string(gotJSON) != mustMarshal(t, expected) {
+					t.Errorf("key %s: expected %s, got %s", key, mustMarshal(t, expected), gotJSON)
+				}
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// splunkSchema is a schema defined outside the built-in ones, using the Entry accessors.
+type splunkSchema struct{}
+
+func (splunkSchema) Attrs(e Entry) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("src", e.IP()),
+		slog.String("http_method", e.Method()),
+		slog.Int("status", e.StatusCode()),
+	}
+	if e.IsAggregate() {
+		return append(attrs, slog.Int("count", e.Count()))
+	}
+	return append(attrs, slog.String("uri_path", e.Path()), slog.Int64("response_time_ms", e.Latency().Milliseconds()))
+}
+
+func TestCustomSchema(t *testing.T) {
+	realtime := logEntry{
+		ip:              "10.0.0.1",
+		method:          "GET",
+		statusCode:      200,
+		count:           1,
+		realtimeDetails: realtimeDetails{path: "/api/users/1", latency: 50 * time.Millisecond},
+		extraFields:     map[string]extraFields{"country": {value: "IT", found: true}},
+	}
+	aggregate := logEntry{ip: "10.0.0.1", method: "GET", statusCode: 200, count: 3, isAggregate: true}
+
+	tests := []struct {
+		name     string
+		entry    logEntry
+		expected map[string]any
+	}{
+		{"Realtime", realtime, map[string]any{"src": "10.0.0.1", "http_method": "GET", "status": 200.0, "uri_path": "/api/users/1", "response_time_ms": 50.0, "country": "IT", "env": "prod"}},
+		{"Aggregate", aggregate, map[string]any{"src": "10.0.0.1", "http_method": "GET", "status": 200.0, "count": 3.0, "env": "prod"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			c := configure(
+				WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+				WithHeaderToLogs(map[string][]string{"country": {"cf-ipcountry"}}),
+				WithStaticLogEntries(map[string]string{"env": "prod"}),
+				WithSchema(splunkSchema{}),
+			)
+			printLog("test", test.entry, c)
+
+			var got map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON output %q: %v", buf.String(), err)
+			}
+			// time, level and msg are added by the handler.
+			if len(got) != len(test.expected)+3 {
+				t.Errorf("expected only the schema fields, named headers and static entries, got %s", buf.String())
+			}
+			for key, expected := range test.expected {
+				if gotJSON, _ := json.Marshal(got[key]); string(gotJSON) != mustMarshal(t, expected) {
+					t.Errorf("key %s: expected %s, got %s", key, mustMarshal(t, expected), gotJSON)
+				}
+			}
+		})
+	}
+}
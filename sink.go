@@ -0,0 +1,305 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSinkBufferSize is the number of entries buffered per sink when SinkBufferSize is not used.
+const defaultSinkBufferSize = 1000
+
+// Sink receives every emitted entry, realtime or aggregated. Sinks added with WithSink run on their own goroutine,
+// so a slow or failing sink does not block requests or the other sinks.
+type Sink interface {
+	Emit(e Entry) error
+}
+
+// Flusher is implemented by sinks that buffer entries, eg: to batch network packets.
+// Flush is called at the end of each aggregation window and when the Logger stops.
+type Flusher interface {
+	Flush() error
+}
+
+// errSinkStopped is returned by flushSinksAndWait when a sink worker had already stopped, so the delivery of its
+// entries could not be confirmed.
+var errSinkStopped = errors.New("slogger: sink stopped")
+
+// binder is implemented by sinks that depend on the Logger configuration or lifetime, eg: OTLPExporter.
+type binder interface {
+	bind(ctx context.Context, c *conf)
+}
+
+// SinkFunc adapts a function to the Sink interface, eg: for custom callbacks.
+type SinkFunc func(e Entry) error
+
+// Emit calls f(e).
+func (f SinkFunc) Emit(e Entry) error {
+	return f(e)
+}
+
+// SinkOption configures a sink added with WithSink.
+type SinkOption func(w *sinkWorker)
+
+// SinkFilter delivers to the sink only the entries for which filter returns true.
+func SinkFilter(filter func(e Entry) bool) SinkOption {
+	return func(w *sinkWorker) {
+		w.filter = filter
+	}
+}
+
+// SinkBufferSize sets the number of entries buffered for the sink. Entries are dropped when the buffer is full.
+func SinkBufferSize(size int) SinkOption {
+	return func(w *sinkWorker) {
+		w.bufferSize = size
+	}
+}
+
+// WithSink adds a destination receiving every entry in addition to the default slog output.
+func WithSink(sink Sink, opts ...SinkOption) Option {
+	return func(c *conf) {
+		w := &sinkWorker{sink: sink, bufferSize: defaultSinkBufferSize}
+		for _, opt := range opts {
+			opt(w)
+		}
+		c.sinks = append(c.sinks, w)
+	}
+}
+
+// WithDefaultOutput enables or disables the default slog output set by WithLogger, eg: when only sinks are used.
+func WithDefaultOutput(enabled bool) Option {
+	return func(c *conf) {
+		c.disableDefaultOutput = !enabled
+	}
+}
+
+// NewSlogSink returns a Sink writing entries to a slog.Logger, using the Schema of the Logger.
+func NewSlogSink(logger *slog.Logger) Sink {
+	return &slogSink{logger: logger}
+}
+
+// NewJSONSink returns a Sink writing entries as JSON lines to w.
+func NewJSONSink(w io.Writer) Sink {
+	return NewSlogSink(slog.New(slog.NewJSONHandler(w, nil)))
+}
+
+type slogSink struct {
+	logger *slog.Logger
+}
+
+// Emit logs the entry at its level with the attributes of the configured schema.
+func (s *slogSink) Emit(e Entry) error {
+	s.logger.Log(context.Background(), e.Level(), e.Message(), e.Attrs()...)
+	return nil
+}
+
+// sinkWorker delivers entries to a sink from a dedicated goroutine through a bounded queue.
+type sinkWorker struct {
+	sink        Sink
+	filter      func(e Entry) bool
+	resolutions []time.Duration
+	bufferSize  int
+	queue       chan sinkItem
+	// closeMu makes enqueue and close mutually exclusive, so no entry is queued once the delivery loop drains the queue.
+	closeMu sync.RWMutex
+	closed  bool
+	// stop is closed by close, and stopped when the delivery loop returns.
+	stop    chan struct{}
+	stopped chan struct{}
+	dropped atomic.Uint64
+}
+
+// sinkItem is either an entry to deliver or a flush request. done, when set, is closed once the flush is complete.
+type sinkItem struct {
+	entry Entry
+	flush bool
+	done  chan struct{}
+}
+
+// start creates the queue and runs the delivery loop until the worker is closed, then delivers the buffered entries
+// and flushes.
+func (w *sinkWorker) start() {
+	w.queue = make(chan sinkItem, w.bufferSize)
+	w.stop = make(chan struct{})
+	w.stopped = make(chan struct{})
+	go func() {
+		defer close(w.stopped)
+		for {
+			select {
+			case item := <-w.queue:
+				w.deliver(item)
+			case <-w.stop:
+				for {
+					select {
+					case item := <-w.queue:
+						w.deliver(item)
+					default:
+						w.flush()
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// close stops the worker once the entries queued so far are delivered. Entries queued afterwards are dropped and counted.
+func (w *sinkWorker) close() {
+	w.closeMu.Lock()
+	defer w.closeMu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	if w.stop != nil {
+		close(w.stop)
+	}
+}
+
+// enqueue hands an entry to the worker, dropping it when the filter rejects it, the queue is full or the worker is
+// closed. Rollup windows are delivered only to the sinks asking for their resolution.
+func (w *sinkWorker) enqueue(e Entry) {
+	if w.filter != nil && !w.filter(e) {
+		return
+	}
+	if e.IsAggregate() {
+		if len(w.resolutions) > 0 && !slices.Contains(w.resolutions, e.Resolution()) || len(w.resolutions) == 0 && e.IsRollup() {
+			return
+		}
+	}
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		w.dropped.Add(1)
+		return
+	}
+	select {
+	case w.queue <- sinkItem{entry: e}:
+	default:
+		w.dropped.Add(1)
+		slog.Warn("sink queue is full, entry dropped")
+	}
+}
+
+// requestFlush asks the worker to flush the sink once the entries queued so far are delivered.
+func (w *sinkWorker) requestFlush() {
+	if _, ok := w.sink.(Flusher); !ok {
+		return
+	}
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.queue <- sinkItem{flush: true}:
+	default:
+	}
+}
+
+// queueFlush queues a flush request behind the entries queued so far, waiting for room in the queue if needed.
+// It returns a channel closed once the entries are delivered and the sink is flushed, errSinkStopped if the worker is
+// closed, or ctx.Err() if ctx is done first.
+func (w *sinkWorker) queueFlush(ctx context.Context) (<-chan struct{}, error) {
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		return nil, errSinkStopped
+	}
+	done := make(chan struct{})
+	select {
+	case w.queue <- sinkItem{flush: true, done: done}:
+		return done, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// deliver emits an entry or flushes the sink.
+func (w *sinkWorker) deliver(item sinkItem) {
+	if item.flush {
+		w.flush()
+		if item.done != nil {
+			close(item.done)
+		}
+		return
+	}
+	w.emit(item.entry)
+}
+
+// emit delivers an entry to the sink, reporting failures without stopping the worker.
+func (w *sinkWorker) emit(e Entry) {
+	if err := w.sink.Emit(e); err != nil {
+		slog.Warn("sink emit failed", slog.Any("error", err))
+	}
+}
+
+// flush flushes the sink if it buffers entries.
+func (w *sinkWorker) flush() {
+	if f, ok := w.sink.(Flusher); ok {
+		if err := f.Flush(); err != nil {
+			slog.Warn("sink flush failed", slog.Any("error", err))
+		}
+	}
+}
+
+// startSinks binds and starts the delivery goroutine of every configured sink that is not running yet.
+func (c *conf) startSinks(ctx context.Context) {
+	for _, w := range c.sinks {
+		if w.queue != nil {
+			continue
+		}
+		if b, ok := w.sink.(binder); ok {
+			b.bind(ctx, c)
+		}
+		w.start()
+	}
+}
+
+// stopSinks closes every sink worker and waits until they have delivered their buffered entries and flushed.
+func (c *conf) stopSinks() {
+	for _, w := range c.sinks {
+		w.close()
+	}
+	for _, w := range c.sinks {
+		if w.stopped != nil {
+			<-w.stopped
+		}
+	}
+}
+
+// flushSinks asks every sink to flush, eg: at the end of an aggregation window.
+func (c *conf) flushSinks() {
+	for _, w := range c.sinks {
+		w.requestFlush()
+	}
+}
+
+// flushSinksAndWait waits until every sink has emitted the entries queued so far and flushed. It returns
+// errSinkStopped if a sink worker is already closed, or ctx.Err() if ctx is done first.
+func (c *conf) flushSinksAndWait(ctx context.Context) error {
+	pending := make([]<-chan struct{}, 0, len(c.sinks))
+	for _, w := range c.sinks {
+		if w.queue == nil {
+			continue
+		}
+		done, err := w.queueFlush(ctx)
+		if err != nil {
+			return err
+		}
+		pending = append(pending, done)
+	}
+	for _, done := range pending {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
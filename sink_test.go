@@ -0,0 +1,245 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type collectSink struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (s *collectSink) Emit(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func (s *collectSink) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSinkFanOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	all := &collectSink{}
+	errorsOnly := &collectSink{}
+	var jsonBuf safeBuffer
+	failing := SinkFunc(func(e Entry) error { return errors.New("unavailable") })
+
+	logger := New(ctx,
+		WithDefaultOutput(false),
+		WithStaticLogEntries(map[string]string{"app": "test"}),
+		WithSink(all),
+		WithSink(errorsOnly, SinkFilter(func(e Entry) bool { return e.StatusCode() >= 500 })),
+		WithSink(NewJSONSink(&jsonBuf)),
+		WithSink(failing),
+	)
+
+	for _, status := range []int{200, 500, 404} {
+		printLog("test", logEntry{statusCode: status, count: 1, method: "GET"}, logger.config())
+	}
+
+	waitFor(t, func() bool { return all.len() == 3 && errorsOnly.len() == 1 })
+	waitFor(t, func() bool { return bytes.Count(jsonBuf.Bytes(), []byte("\n")) == 3 })
+
+	if errorsOnly.entries[0].StatusCode() != 500 {
+		t.Errorf("expected filtered entry with status 500, got %d", errorsOnly.entries[0].StatusCode())
+	}
+	if all.entries[0].StaticFields()["app"] != "test" {
+		t.Errorf("expected static fields in entry, got %v", all.entries[0].StaticFields())
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal(bytes.SplitN(jsonBuf.Bytes(), []byte("\n"), 2)[0], &line); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if line["statusCode"] != 200.0 || line["app"] != "test" {
+		t.Errorf("unexpected JSON line %v", line)
+	}
+}
+
+func TestSinkIsolation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	blocking := SinkFunc(func(e Entry) error {
+		<-release
+		return nil
+	})
+	fast := &collectSink{}
+
+	logger := New(ctx,
+		WithLogger(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))),
+		WithSink(blocking, SinkBufferSize(1)),
+		WithSink(fast),
+	)
+
+	for i := 0; i < 10; i++ {
+		printLog("test", logEntry{statusCode: 200, count: 1}, logger.config())
+	}
+	waitFor(t, func() bool { return fast.len() == 10 })
+	if logger.SinkDroppedCount() == 0 {
+		t.Error("expected entries dropped by the blocked sink")
+	}
+	close(release)
+}
+
+// safeBuffer is a bytes.Buffer safe for concurrent use.
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+func (b *safeBuffer) String() string {
+	return string(b.Bytes())
+}
+
+// slowFlushSink emits and flushes slowly, recording the flushes.
+type slowFlushSink struct {
+	collectSink
+	flushes int
+}
+
+func (s *slowFlushSink) Emit(e Entry) error {
+	time.Sleep(5 * time.Millisecond)
+	return s.collectSink.Emit(e)
+}
+
+func (s *slowFlushSink) Flush() error {
+	time.Sleep(5 * time.Millisecond)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushes++
+	return nil
+}
+
+func TestFlushWaitsForSinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		bufferSize int
+	}{
+		{"QueuedEntries", 10},
+		// The flush request waits for room in a full queue instead of being dropped.
+		{"FullQueue", 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			slow := &slowFlushSink{}
+			plain := &collectSink{}
+			logger := New(ctx, WithDefaultOutput(false), WithSink(slow, SinkBufferSize(test.bufferSize)), WithSink(plain))
+			for i := 0; i < 3; i++ {
+				printLog("test", logEntry{statusCode: 200, count: 1}, logger.config())
+			}
+
+			flushCtx, flushCancel := context.WithTimeout(context.Background(), time.Second)
+			defer flushCancel()
+			if err := logger.Flush(flushCtx); err != nil {
+				t.Fatalf("unexpected flush error: %v", err)
+			}
+			slow.mu.Lock()
+			emitted, flushes := len(slow.entries), slow.flushes
+			slow.mu.Unlock()
+			if emitted != 3 || flushes != 1 || plain.len() != 3 {
+				t.Errorf("expected every entry emitted and one flush before Flush returns, got %d entries, %d flushes and %d entries", emitted, flushes, plain.len())
+			}
+		})
+	}
+}
+
+func TestFlushSinkDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+	blocking := SinkFunc(func(e Entry) error {
+		<-release
+		return nil
+	})
+	logger := New(ctx, WithDefaultOutput(false), WithSink(blocking))
+	printLog("test", logEntry{statusCode: 200, count: 1}, logger.config())
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer flushCancel()
+	if err := logger.Flush(flushCtx); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestSinksStopAfterLastWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sink := &collectSink{}
+	logger := New(ctx, WithTimeAggregation(time.Hour), WithQueueSize(300), WithAsync(), WithDefaultOutput(false), WithSink(sink))
+	for i := 0; i < 300; i++ {
+		logger.send(logEntry{ip: "10.0.0.1", method: "GET", aggregatePath: "/", statusCode: 200, isAggregate: true})
+	}
+	logger.emit(logEntry{statusCode: 200, count: 1}, logger.config())
+	_ = logger.Snapshot()
+	cancel()
+
+	select {
+	case <-logger.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the Logger to stop")
+	}
+	requests, realtime := 0, 0
+	for _, e := range sink.entries {
+		if e.IsAggregate() {
+			requests += e.Count()
+		} else {
+			realtime++
+		}
+	}
+	if requests != 300 || realtime != 1 {
+		t.Errorf("expected the last window and the async entry delivered before the sinks stop, got %d requests and %d entries", requests, realtime)
+	}
+
+	printLog("test", logEntry{statusCode: 200, count: 1}, logger.config())
+	if got := logger.SinkDroppedCount(); got != 1 {
+		t.Errorf("expected an entry emitted after the Logger stopped to be counted as dropped, got %d", got)
+	}
+	if err := logger.Flush(context.Background()); err != nil {
+		t.Errorf("unexpected flush error after the Logger stopped: %v", err)
+	}
+}
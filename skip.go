@@ -0,0 +1,114 @@
+package slogger
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SkipRule reports whether a request must be excluded from logging.
+// Rules run once per request, after the handler chain, so they can inspect the response status.
+// Any func(*gin.Context) bool can be used as a SkipRule.
+type SkipRule func(c *gin.Context) bool
+
+// WithSkipRules appends rules used to exclude requests from logging, in addition to the exact paths set by WithSkipPaths.
+func WithSkipRules(rules ...SkipRule) Option {
+	return func(c *conf) {
+		c.skipRules = append(c.skipRules, rules...)
+	}
+}
+
+// WithSkippedCounter enables counting of skipped requests, readable through Logger.SkippedCount.
+func WithSkippedCounter(enabled bool) Option {
+	return func(c *conf) {
+		c.countSkipped = enabled
+	}
+}
+
+// SkipPathPrefix skips requests whose URL path starts with one of the given prefixes.
+func SkipPathPrefix(prefixes ...string) SkipRule {
+	return func(c *gin.Context) bool {
+		p := c.Request.URL.Path
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(p, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SkipRouteGlob skips requests whose matched route (c.FullPath()) matches one of the given path.Match patterns.
+// Invalid patterns never match.
+func SkipRouteGlob(patterns ...string) SkipRule {
+	return func(c *gin.Context) bool {
+		route := c.FullPath()
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, route); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SkipPathRegexp skips requests whose URL path matches the given regular expression.
+func SkipPathRegexp(re *regexp.Regexp) SkipRule {
+	return func(c *gin.Context) bool {
+		return re.MatchString(c.Request.URL.Path)
+	}
+}
+
+// SkipMethods skips requests using one of the given HTTP methods, eg: OPTIONS or HEAD.
+func SkipMethods(methods ...string) SkipRule {
+	set := make(map[string]struct{}, len(methods))
+	for _, m := range methods {
+		set[strings.ToUpper(m)] = struct{}{}
+	}
+	return func(c *gin.Context) bool {
+		_, ok := set[c.Request.Method]
+		return ok
+	}
+}
+
+// SkipStatusCodes skips requests answered with one of the given status codes.
+func SkipStatusCodes(codes ...int) SkipRule {
+	set := make(map[int]struct{}, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+	return func(c *gin.Context) bool {
+		_, ok := set[c.Writer.Status()]
+		return ok
+	}
+}
+
+// SkipUserAgents skips requests whose user agent contains one of the given substrings, compared case-insensitively.
+// It is meant for health-check probes such as "kube-probe" or "ELB-HealthChecker".
+func SkipUserAgents(substrings ...string) SkipRule {
+	lowered := make([]string, 0, len(substrings))
+	for _, s := range substrings {
+		lowered = append(lowered, strings.ToLower(s))
+	}
+	return func(c *gin.Context) bool {
+		ua := strings.ToLower(c.Request.UserAgent())
+		for _, s := range lowered {
+			if strings.Contains(ua, s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// shouldSkip evaluates the configured skip rules, stopping at the first match.
+func (c *conf) shouldSkip(ctx *gin.Context) bool {
+	for _, rule := range c.skipRules {
+		if rule(ctx) {
+			return true
+		}
+	}
+	return false
+}
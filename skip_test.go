@@ -0,0 +1,72 @@
+package slogger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSkipRules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		rules        []SkipRule
+		method       string
+		target       string
+		userAgent    string
+		expectedSkip bool
+	}{
+		{"NoRules", nil, http.MethodGet, "/api/users/1", "", false},
+		{"PrefixMatch", []SkipRule{SkipPathPrefix("/static/")}, http.MethodGet, "/static/app.js", "", true},
+		{"PrefixNoMatch", []SkipRule{SkipPathPrefix("/static/")}, http.MethodGet, "/api/users/1", "", false},
+		{"GlobMatch", []SkipRule{SkipRouteGlob("/api/users/*")}, http.MethodGet, "/api/users/1", "", true},
+		{"GlobNoMatch", []SkipRule{SkipRouteGlob("/api/orders/*")}, http.MethodGet, "/api/users/1", "", false},
+		{"GlobInvalidPattern", []SkipRule{SkipRouteGlob("[")}, http.MethodGet, "/api/users/1", "", false},
+		{"RegexpMatch", []SkipRule{SkipPathRegexp(regexp.MustCompile(`^/api/users/\d+$`))}, http.MethodGet, "/api/users/1", "", true},
+		{"MethodMatch", []SkipRule{SkipMethods("options", "HEAD")}, http.MethodHead, "/api/users/1", "", true},
+		{"MethodNoMatch", []SkipRule{SkipMethods("OPTIONS")}, http.MethodGet, "/api/users/1", "", false},
+		{"StatusMatch", []SkipRule{SkipStatusCodes(http.StatusNotFound)}, http.MethodGet, "/missing", "", true},
+		{"StatusNoMatch", []SkipRule{SkipStatusCodes(http.StatusNotFound)}, http.MethodGet, "/api/users/1", "", false},
+		{"UserAgentMatch", []SkipRule{SkipUserAgents("kube-probe")}, http.MethodGet, "/api/users/1", "Kube-Probe/1.29", true},
+		{"Predicate", []SkipRule{func(c *gin.Context) bool { return c.Query("debug") == "1" }}, http.MethodGet, "/api/users/1?debug=1", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(context.Background(),
+				WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+				WithSkipRules(test.rules...),
+				WithSkippedCounter(true),
+			)
+			r := gin.New()
+			r.Use(logger.Middleware())
+			r.Any("/api/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+			r.GET("/static/*file", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(test.method, test.target, nil)
+			req.Header.Set("User-Agent", test.userAgent)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			skipped := !strings.Contains(buf.String(), "msg=")
+			if skipped != test.expectedSkip {
+				t.Errorf("expected skip %v, got %v: %s", test.expectedSkip, skipped, buf.String())
+			}
+			expectedCount := uint64(0)
+			if test.expectedSkip {
+				expectedCount = 1
+			}
+			if logger.SkippedCount() != expectedCount {
+				t.Errorf("expected skipped count %d, got %d", expectedCount, logger.SkippedCount())
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package slogger
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultSnapshotHistory is the number of completed windows kept for Snapshot when WithSnapshotHistory is not used.
+const defaultSnapshotHistory = 6
+
+// WithSnapshotHistory sets the number of completed aggregation windows kept in memory for Logger.Snapshot. Zero keeps none.
+func WithSnapshotHistory(windows int) Option {
+	return func(c *conf) {
+		c.snapshotHistory = windows
+	}
+}
+
+// Snapshot is a copy of the aggregation state: the window in progress and the last completed windows.
+type Snapshot struct {
+	// Aggregating is false when the aggregator is not running, in which case the snapshot is empty.
+	Aggregating bool
+	Current     WindowStats
+	// History holds the last completed windows, most recent first.
+	History []WindowStats
+}
+
+// WindowStats are the aggregated requests of a window.
+type WindowStats struct {
+	Start time.Time
+	// End is zero while the window is in progress.
+	End      time.Time
+	Interval time.Duration
+	Requests int
+	Buckets  []BucketStats
+	// Top holds the heavy hitters of the window, when WithTopK is used.
+	Top TopK
+	// Routes holds the distinct clients per route, when WithUniqueClients is used.
+	Routes []RouteStats
+	// OverflowedKeys is the estimated number of distinct keys folded into overflow buckets, see WithMaxAggregationKeys.
+	OverflowedKeys int
+}
+
+// BucketStats are the statistics of an aggregation bucket: the requests of a window sharing the same IP, user agent,
+// method, protocol, status code and aggregate path.
+type BucketStats struct {
+	Method          string
+	AggregatePath   string
+	StatusCode      int
+	IP              string
+	UserAgent       string
+	Proto           string
+	Count           int
+	SumLatency      time.Duration
+	MinLatency      time.Duration
+	MaxLatency      time.Duration
+	SumResponseSize int
+	SlowCount       int
+	// IsBot is meaningful only when BotDetected is true, ie: a BotDetector is configured.
+	IsBot       bool
+	BotDetected bool
+}
+
+// MeanLatency returns the mean latency of the bucket.
+func (b BucketStats) MeanLatency() time.Duration {
+	if b.Count == 0 {
+		return 0
+	}
+	return b.SumLatency / time.Duration(b.Count)
+}
+
+// Snapshot returns a copy of the aggregation window in progress and of the last completed windows, as configured with
+// WithSnapshotHistory. It is safe for concurrent use, eg: from health checks or dashboards.
+func (a *Logger) Snapshot() Snapshot {
+	w, ok := a.currentWindow()
+	if !ok {
+		return Snapshot{}
+	}
+	s := Snapshot{Aggregating: true, Current: w.stats(), History: make([]WindowStats, 0, len(w.history))}
+	for _, h := range w.history {
+		s.History = append(s.History, h.stats())
+	}
+	return s
+}
+
+// stats converts a window to its public form, with buckets sorted by decreasing count.
+func (w window) stats() WindowStats {
+	ws := WindowStats{Start: w.start, End: w.end, Interval: w.interval, Top: w.top, Routes: w.routes, OverflowedKeys: w.overflowedKeys, Buckets: make([]BucketStats, 0, len(w.entries))}
+	for _, v := range w.entries {
+		ws.Requests += v.count
+		ws.Buckets = append(ws.Buckets, BucketStats{
+			Method:          v.method,
+			AggregatePath:   v.aggregatePath,
+			StatusCode:      v.statusCode,
+			IP:              v.ip,
+			UserAgent:       v.ua,
+			Proto:           v.proto,
+			Count:           v.count,
+			SumLatency:      v.sumLatency,
+			MinLatency:      v.minLatency,
+			MaxLatency:      v.maxLatency,
+			SumResponseSize: v.sumSizeRespoBody,
+			SlowCount:       v.slowCount,
+			IsBot:           v.isBot == 1,
+			BotDetected:     v.isBotDetectorEnabled,
+		})
+	}
+	slices.SortFunc(ws.Buckets, func(x, y BucketStats) int {
+		if x.Count != y.Count {
+			return y.Count - x.Count
+		}
+		return strings.Compare(x.AggregatePath, y.AggregatePath)
+	})
+	return ws
+}
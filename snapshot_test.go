@@ -0,0 +1,73 @@
+package slogger
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	windows := make(chan struct{}, 10)
+	logger := New(ctx, WithTimeAggregation(50*time.Millisecond), WithSnapshotHistory(2), WithDefaultOutput(false), WithAfterEntry(func(e Entry) {
+		windows <- struct{}{}
+	}))
+
+	for window := 0; window < 3; window++ {
+		for i := 0; i <= window; i++ {
+			logger.send(logEntry{method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK, realtimeDetails: realtimeDetails{latency: time.Duration(i+1) * time.Millisecond}})
+		}
+		<-windows
+	}
+	logger.send(logEntry{method: http.MethodPost, aggregatePath: "/api", statusCode: http.StatusCreated, realtimeDetails: realtimeDetails{latency: time.Millisecond}})
+	logger.send(logEntry{method: http.MethodPost, aggregatePath: "/api", statusCode: http.StatusCreated, realtimeDetails: realtimeDetails{latency: 3 * time.Millisecond}})
+
+	s := logger.Snapshot()
+	if !s.Aggregating {
+		t.Fatal("expected the aggregator to be running")
+	}
+	if s.Current.Requests != 2 || !s.Current.End.IsZero() || len(s.Current.Buckets) != 1 {
+		t.Fatalf("unexpected current window %+v", s.Current)
+	}
+	if b := s.Current.Buckets[0]; b.Method != http.MethodPost || b.Count != 2 || b.MeanLatency() != 2*time.Millisecond || b.MaxLatency != 3*time.Millisecond {
+		t.Errorf("unexpected bucket %+v", b)
+	}
+	if len(s.History) != 2 {
+		t.Fatalf("expected 2 completed windows, got %d", len(s.History))
+	}
+	if s.History[0].Requests != 3 || s.History[1].Requests != 2 {
+		t.Errorf("expected the most recent windows first, got %d and %d requests", s.History[0].Requests, s.History[1].Requests)
+	}
+	if !s.History[0].End.After(s.History[0].Start) || s.History[0].Start.Before(s.History[1].End) {
+		t.Errorf("unexpected window bounds %+v", s.History)
+	}
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := New(ctx, WithTimeAggregation(time.Millisecond), WithDefaultOutput(false))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.send(logEntry{method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+				_ = logger.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSnapshotWithoutAggregation(t *testing.T) {
+	if s := New(context.Background()).Snapshot(); s.Aggregating || len(s.History) != 0 {
+		t.Errorf("expected an empty snapshot, got %+v", s)
+	}
+}
@@ -0,0 +1,233 @@
+package slogger
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultStatsDPacketSize keeps StatsD packets within a typical Ethernet MTU once IP and UDP headers are added.
+const defaultStatsDPacketSize = 1432
+
+// StatsDOption configures a StatsDSink.
+type StatsDOption func(s *StatsDSink)
+
+// StatsDPrefix sets the prefix of metric names, eg: "myapp" gives myapp.http.requests.
+func StatsDPrefix(prefix string) StatsDOption {
+	return func(s *StatsDSink) {
+		s.prefix = prefix
+	}
+}
+
+// StatsDTags enables DogStatsD tags. Without tags, the aggregation dimensions are appended to the metric names.
+func StatsDTags(enabled bool) StatsDOption {
+	return func(s *StatsDSink) {
+		s.tags = enabled
+	}
+}
+
+// StatsDPacketSize sets the maximum size of a UDP packet. Metrics are batched up to this size.
+func StatsDPacketSize(size int) StatsDOption {
+	return func(s *StatsDSink) {
+		s.packetSize = size
+	}
+}
+
+// StatsDSink is a Sink sending aggregated windows to a StatsD or DogStatsD endpoint over UDP.
+// The buckets of a window sharing the same metric names and tags, eg: the buckets of a route for different clients,
+// are merged until the window is flushed. For each of them it sends a request counter, the count-weighted mean and
+// the min/max latency timings, a mean response size gauge and, when slow detection is enabled, a slow request counter.
+// Realtime entries, summaries and rollup windows are ignored, so the counters are not sent once per resolution.
+type StatsDSink struct {
+	mu         sync.Mutex
+	conn       net.Conn
+	prefix     string
+	tags       bool
+	packetSize int
+	buf        bytes.Buffer
+	// points holds the merged buckets of the window in progress, and keys their order of arrival.
+	points map[statsDKey]*statsDPoint
+	keys   []statsDKey
+}
+
+// statsDKey identifies the metrics of an aggregated entry: the prefix of their names and their tags.
+type statsDKey struct {
+	name string
+	tags string
+}
+
+// statsDPoint accumulates the aggregated entries of a window sharing the same metric names and tags.
+type statsDPoint struct {
+	count       int
+	slowCount   int
+	sumLatency  time.Duration
+	minLatency  time.Duration
+	maxLatency  time.Duration
+	sumRespSize int
+	slowEnabled bool
+}
+
+// NewStatsDSink returns a StatsDSink sending to addr, eg: "127.0.0.1:8125".
+func NewStatsDSink(addr string, opts ...StatsDOption) (*StatsDSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &StatsDSink{conn: conn, prefix: "http", packetSize: defaultStatsDPacketSize, points: make(map[statsDKey]*statsDPoint)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Emit merges an aggregated entry into the metrics of the window in progress, which are sent by Flush.
+func (s *StatsDSink) Emit(e Entry) error {
+	if !e.IsAggregate() || e.IsSummary() || e.IsRollup() {
+		return nil
+	}
+	name, tags := s.dimensions(e)
+	key := statsDKey{name: name, tags: tags}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.points[key]
+	if !ok {
+		p = &statsDPoint{minLatency: e.MinLatency(), maxLatency: e.MaxLatency()}
+		s.points[key] = p
+		s.keys = append(s.keys, key)
+	}
+	p.count += e.Count()
+	p.slowCount += e.SlowCount()
+	p.sumLatency += e.SumLatency()
+	p.minLatency = min(p.minLatency, e.MinLatency())
+	p.maxLatency = max(p.maxLatency, e.MaxLatency())
+	p.sumRespSize += e.ResponseSize()
+	p.slowEnabled = p.slowEnabled || (e.c != nil && e.c.isSlowDetectionEnabled())
+	return nil
+}
+
+// Flush sends the merged metrics of the window, batched in packets of at most the configured size.
+func (s *StatsDSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys, points := s.keys, s.points
+	s.keys, s.points = nil, make(map[statsDKey]*statsDPoint)
+	for _, key := range keys {
+		for _, l := range s.lines(key, points[key]) {
+			if s.buf.Len() > 0 && s.buf.Len()+1+len(l) > s.packetSize {
+				if err := s.send(); err != nil {
+					return err
+				}
+			}
+			if s.buf.Len() > 0 {
+				s.buf.WriteByte('\n')
+			}
+			s.buf.WriteString(l)
+		}
+	}
+	return s.send()
+}
+
+// lines returns the metrics of merged entries.
+func (s *StatsDSink) lines(key statsDKey, p *statsDPoint) []string {
+	var meanLatency time.Duration
+	var meanRespSize float64
+	if p.count > 0 {
+		meanLatency = p.sumLatency / time.Duration(p.count)
+		meanRespSize = float64(p.sumRespSize) / float64(p.count)
+	}
+	lines := []string{
+		s.line(key.name+"requests", strconv.Itoa(p.count), "c", key.tags),
+		s.line(key.name+"latency.mean", formatMillis(meanLatency), "ms", key.tags),
+		s.line(key.name+"latency.min", formatMillis(p.minLatency), "ms", key.tags),
+		s.line(key.name+"latency.max", formatMillis(p.maxLatency), "ms", key.tags),
+		s.line(key.name+"response_size.mean", strconv.FormatFloat(meanRespSize, 'f', -1, 64), "g", key.tags),
+	}
+	if p.slowEnabled {
+		lines = append(lines, s.line(key.name+"slow", strconv.Itoa(p.slowCount), "c", key.tags))
+	}
+	return lines
+}
+
+// Close flushes the buffered metrics and closes the connection.
+func (s *StatsDSink) Close() error {
+	err := s.Flush()
+	if cerr := s.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// send writes the buffer as a single packet. The caller must hold the lock.
+func (s *StatsDSink) send() error {
+	if s.buf.Len() == 0 {
+		return nil
+	}
+	_, err := s.conn.Write(s.buf.Bytes())
+	s.buf.Reset()
+	return err
+}
+
+// dimensions returns the metric name prefix and the tags of an entry. Without DogStatsD tags the dimensions are
+// part of the name, eg: http.GET.api_users.2xx.requests.
+func (s *StatsDSink) dimensions(e Entry) (name string, tags string) {
+	isBot, detected := e.IsBot()
+	if s.tags {
+		t := []string{
+			"method:" + sanitizeStatsDTag(e.Method()),
+			"aggregate_path:" + sanitizeStatsDTag(e.AggregatePath()),
+			"status_code:" + strconv.Itoa(e.StatusCode()),
+			"status_class:" + statusClass(e.StatusCode()),
+		}
+		if detected {
+			t = append(t, "is_bot:"+strconv.FormatBool(isBot))
+		}
+		return s.prefix + ".", strings.Join(t, ",")
+	}
+	name = s.prefix + "." + sanitizeStatsD(e.Method()) + "." + sanitizeStatsD(e.AggregatePath()) + "." + statusClass(e.StatusCode()) + "."
+	if detected && isBot {
+		name += "bot."
+	}
+	return name, ""
+}
+
+// line formats a metric in the StatsD line protocol, with DogStatsD tags when present.
+func (s *StatsDSink) line(name, value, metricType, tags string) string {
+	l := name + ":" + value + "|" + metricType
+	if tags != "" {
+		l += "|#" + tags
+	}
+	return l
+}
+
+// formatMillis formats a duration in milliseconds.
+func formatMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
+
+// sanitizeStatsD replaces the characters not allowed in a StatsD metric name segment with underscores.
+func sanitizeStatsD(s string) string {
+	s = strings.Trim(s, "/")
+	if s == "" {
+		return "root"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// statsDTagReplacer replaces the characters separating DogStatsD fields, tags and datagrams, which would otherwise let
+// a tag value corrupt the packet or inject metrics.
+var statsDTagReplacer = strings.NewReplacer(",", "_", "|", "_", ":", "_", "#", "_", "\n", "_", "\r", "_")
+
+// sanitizeStatsDTag replaces the characters not allowed in a DogStatsD tag value with underscores.
+func sanitizeStatsDTag(s string) string {
+	return statsDTagReplacer.Replace(s)
+}
@@ -0,0 +1,205 @@
+package slogger
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listenUDP(t *testing.T) net.PacketConn {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	return pc
+}
+
+func readPacket(t *testing.T, pc net.PacketConn) string {
+	t.Helper()
+	buf := make([]byte, 65535)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no packet received: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func aggregatedEntry() Entry {
+	return newEntry("test", logEntry{
+		isAggregate:          true,
+		method:               "GET",
+		aggregatePath:        "/api/users/:id",
+		statusCode:           200,
+		count:                4,
+		isBotDetectorEnabled: true,
+		aggregateDetails: aggregateDetails{
+			sumLatency:       40 * time.Millisecond,
+			minLatency:       5 * time.Millisecond,
+			maxLatency:       20 * time.Millisecond,
+			sumSizeRespoBody: 400,
+			slowCount:        1,
+		},
+	}, configure(WithSlowThreshold(10*time.Millisecond, nil)))
+}
+
+func TestStatsDSink(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []StatsDOption
+		expected string
+	}{
+		{"Plain", []StatsDOption{StatsDPrefix("app")}, strings.Join([]string{
+			"app.GET.api_users__id.2xx.requests:4|c",
+			"app.GET.api_users__id.2xx.latency.mean:10|ms",
+			"app.GET.api_users__id.2xx.latency.min:5|ms",
+			"app.GET.api_users__id.2xx.latency.max:20|ms",
+			"app.GET.api_users__id.2xx.response_size.mean:100|g",
+			"app.GET.api_users__id.2xx.slow:1|c",
+		}, "\n")},
+		{"DogStatsD", []StatsDOption{StatsDTags(true)}, strings.Join([]string{
+			"http.requests:4|c|#method:GET,aggregate_path:/api/users/_id,status_code:200,status_class:2xx,is_bot:false",
+			"http.latency.mean:10|ms|#method:GET,aggregate_path:/api/users/_id,status_code:200,status_class:2xx,is_bot:false",
+			"http.latency.min:5|ms|#method:GET,aggregate_path:/api/users/_id,status_code:200,status_class:2xx,is_bot:false",
+			"http.latency.max:20|ms|#method:GET,aggregate_path:/api/users/_id,status_code:200,status_class:2xx,is_bot:false",
+			"http.response_size.mean:100|g|#method:GET,aggregate_path:/api/users/_id,status_code:200,status_class:2xx,is_bot:false",
+			"http.slow:1|c|#method:GET,aggregate_path:/api/users/_id,status_code:200,status_class:2xx,is_bot:false",
+		}, "\n")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pc := listenUDP(t)
+			s, err := NewStatsDSink(pc.LocalAddr().String(), test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			if err := s.Emit(newEntry("test", logEntry{statusCode: 200}, nil)); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Emit(aggregatedEntry()); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := readPacket(t, pc); got != test.expected {
+				t.Errorf("unexpected packet:\ngot:\n%s\nwant:\n%s", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestStatsDSinkBatchesToPacketSize(t *testing.T) {
+	pc := listenUDP(t)
+	s, err := NewStatsDSink(pc.LocalAddr().String(), StatsDTags(true), StatsDPacketSize(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, path := range []string{"/a", "/b", "/c"} {
+		e := aggregatedEntry()
+		e.e.aggregatePath = path
+		if err := s.Emit(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := 0
+	for lines < 18 {
+		packet := readPacket(t, pc)
+		if len(packet) > 200 {
+			t.Errorf("packet of %d bytes exceeds the configured size", len(packet))
+		}
+		lines += len(strings.Split(packet, "\n"))
+	}
+	if lines != 18 {
+		t.Errorf("expected 18 metrics, got %d", lines)
+	}
+}
+
+func TestStatsDSinkMergesBuckets(t *testing.T) {
+	pc := listenUDP(t)
+	s, err := NewStatsDSink(pc.LocalAddr().String(), StatsDTags(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Two clients of the same route: the buckets differ by IP and user agent only, so they share their metrics.
+	first := aggregatedEntry()
+	first.e.ip = "10.0.0.1"
+	second := newEntry("test", logEntry{
+		isAggregate:   true,
+		ip:            "10.0.0.2",
+		method:        "GET",
+		aggregatePath: "/api/users/:id",
+		statusCode:    200,
+		count:         1,
+		aggregateDetails: aggregateDetails{
+			sumLatency:       100 * time.Millisecond,
+			minLatency:       100 * time.Millisecond,
+			maxLatency:       100 * time.Millisecond,
+			sumSizeRespoBody: 1100,
+		},
+	}, nil)
+	second.e.isBotDetectorEnabled = true
+	for _, e := range []Entry{first, second} {
+		if err := s.Emit(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	tags := "|#method:GET,aggregate_path:/api/users/_id,status_code:200,status_class:2xx,is_bot:false"
+	expected := strings.Join([]string{
+		"http.requests:5|c" + tags,
+		"http.latency.mean:28|ms" + tags,
+		"http.latency.min:5|ms" + tags,
+		"http.latency.max:100|ms" + tags,
+		"http.response_size.mean:300|g" + tags,
+		"http.slow:1|c" + tags,
+	}, "\n")
+	if got := readPacket(t, pc); got != expected {
+		t.Errorf("unexpected packet:\ngot:\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestStatsDSinkSanitizesTags(t *testing.T) {
+	pc := listenUDP(t)
+	s, err := NewStatsDSink(pc.LocalAddr().String(), StatsDTags(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	e := aggregatedEntry()
+	e.e.method = "GET|x"
+	e.e.aggregatePath = "/a,b:c#d\nevil.metric:1|c"
+	if err := s.Emit(e); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(readPacket(t, pc), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 metrics, got %d: %q", len(lines), lines)
+	}
+	expected := "http.requests:4|c|#method:GET_x,aggregate_path:/a_b_c_d_evil.metric_1_c,status_code:200,status_class:2xx,is_bot:false"
+	if lines[0] != expected {
+		t.Errorf("unexpected metric:\ngot:  %s\nwant: %s", lines[0], expected)
+	}
+}
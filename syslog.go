@@ -0,0 +1,371 @@
+package slogger
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogFacility is a syslog facility code.
+type SyslogFacility int
+
+// Syslog facilities commonly used for application logs.
+const (
+	FacilityUser   SyslogFacility = 1
+	FacilityDaemon SyslogFacility = 3
+	FacilityLocal0 SyslogFacility = 16
+	FacilityLocal1 SyslogFacility = 17
+	FacilityLocal2 SyslogFacility = 18
+	FacilityLocal3 SyslogFacility = 19
+	FacilityLocal4 SyslogFacility = 20
+	FacilityLocal5 SyslogFacility = 21
+	FacilityLocal6 SyslogFacility = 22
+	FacilityLocal7 SyslogFacility = 23
+)
+
+// SyslogFormat selects the syslog message format.
+type SyslogFormat int
+
+const (
+	// SyslogRFC5424 formats messages as RFC 5424 with the HTTP fields in structured data elements.
+	SyslogRFC5424 SyslogFormat = iota
+	// SyslogRFC3164 formats messages as BSD syslog, with the HTTP fields as key=value pairs in the message.
+	SyslogRFC3164
+)
+
+// defaultSyslogEnterpriseID is the example private enterprise number reserved by RFC 5612 for documentation.
+const defaultSyslogEnterpriseID = 32473
+
+// SyslogOption configures a SyslogSink.
+type SyslogOption func(s *SyslogSink)
+
+// SyslogWithFacility sets the facility of the messages. The default is FacilityLocal0.
+func SyslogWithFacility(facility SyslogFacility) SyslogOption {
+	return func(s *SyslogSink) {
+		s.facility = facility
+	}
+}
+
+// SyslogAppName sets the APP-NAME (RFC 5424) or TAG (RFC 3164) of the messages. The default is the program name.
+func SyslogAppName(name string) SyslogOption {
+	return func(s *SyslogSink) {
+		s.appName = name
+	}
+}
+
+// SyslogHostname sets the HOSTNAME of the messages. The default is os.Hostname.
+func SyslogHostname(hostname string) SyslogOption {
+	return func(s *SyslogSink) {
+		s.hostname = hostname
+	}
+}
+
+// SyslogWithFormat sets the message format. The default is SyslogRFC5424.
+func SyslogWithFormat(format SyslogFormat) SyslogOption {
+	return func(s *SyslogSink) {
+		s.format = format
+	}
+}
+
+// SyslogEnterpriseID sets the private enterprise number used in the RFC 5424 structured data IDs, eg: http@32473.
+func SyslogEnterpriseID(id int) SyslogOption {
+	return func(s *SyslogSink) {
+		s.enterpriseID = id
+	}
+}
+
+// SyslogTLSConfig sets the TLS configuration used by the "tls" network.
+func SyslogTLSConfig(config *tls.Config) SyslogOption {
+	return func(s *SyslogSink) {
+		s.tlsConfig = config
+	}
+}
+
+// SyslogDialTimeout sets the timeout of connection attempts. The default is 5s.
+func SyslogDialTimeout(timeout time.Duration) SyslogOption {
+	return func(s *SyslogSink) {
+		s.dialTimeout = timeout
+	}
+}
+
+// SyslogSink is a Sink sending entries to a syslog server over "udp", "tcp", "tls", "unix" or "unixgram".
+// Stream transports use octet-counting framing (RFC 6587). The connection is re-established when a write fails.
+type SyslogSink struct {
+	network      string
+	addr         string
+	facility     SyslogFacility
+	appName      string
+	hostname     string
+	format       SyslogFormat
+	enterpriseID int
+	tlsConfig    *tls.Config
+	dialTimeout  time.Duration
+	pid          string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink returns a SyslogSink sending to addr over network. The connection is opened immediately.
+func NewSyslogSink(network, addr string, opts ...SyslogOption) (*SyslogSink, error) {
+	hostname, _ := os.Hostname()
+	s := &SyslogSink{
+		network:      network,
+		addr:         addr,
+		facility:     FacilityLocal0,
+		appName:      appName(),
+		hostname:     hostname,
+		format:       SyslogRFC5424,
+		enterpriseID: defaultSyslogEnterpriseID,
+		dialTimeout:  5 * time.Second,
+		pid:          strconv.Itoa(os.Getpid()),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6", "tls", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("syslog: unsupported network %q", network)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// appName returns the base name of the running program.
+func appName() string {
+	name := os.Args[0]
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// Emit formats and sends an entry, reconnecting once if the write fails.
+func (s *SyslogSink) Emit(e Entry) error {
+	msg := s.format.render(s, e)
+	if s.isStream() {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	if _, err := s.conn.Write([]byte(msg)); err == nil {
+		return nil
+	}
+	_ = s.conn.Close()
+	s.conn = nil
+	if err := s.connect(); err != nil {
+		return err
+	}
+	_, err := s.conn.Write([]byte(msg))
+	return err
+}
+
+// Close closes the connection.
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// connect opens the connection. The caller must hold the lock.
+func (s *SyslogSink) connect() error {
+	dialer := &net.Dialer{Timeout: s.dialTimeout}
+	var conn net.Conn
+	var err error
+	if s.network == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.addr, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial(s.network, s.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("syslog: %w", err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// isStream reports whether messages need octet-counting framing.
+func (s *SyslogSink) isStream() bool {
+	switch s.network {
+	case "tcp", "tcp4", "tcp6", "tls", "unix":
+		return true
+	}
+	return false
+}
+
+// syslogSeverity maps a slog level to a syslog severity: error=3, warning=4, info=6, debug=7.
+func syslogSeverity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// render formats an entry according to the format.
+func (f SyslogFormat) render(s *SyslogSink, e Entry) string {
+	pri := "<" + strconv.Itoa(int(s.facility)*8+syslogSeverity(e.Level())) + ">"
+	fields := syslogFields(e)
+
+	if f == SyslogRFC3164 {
+		var b strings.Builder
+		b.WriteString(pri)
+		b.WriteString(time.Now().Format(time.Stamp))
+		b.WriteString(" " + syslogToken(s.hostname) + " " + syslogToken(s.appName) + "[" + s.pid + "]: " + e.Message())
+		for _, kv := range fields {
+			b.WriteString(" " + kv[0] + "=" + strconv.Quote(kv[1]))
+		}
+		for _, k := range slices.Sorted(maps.Keys(e.StaticFields())) {
+			b.WriteString(" " + k + "=" + strconv.Quote(e.StaticFields()[k]))
+		}
+		for _, k := range slices.Sorted(maps.Keys(e.Tags())) {
+			b.WriteString(" " + k + "=" + strconv.Quote(e.Tags()[k]))
+		}
+		return b.String()
+	}
+
+	msgID := "access"
+	if e.IsSummary() {
+		msgID = "summary"
+	} else if e.IsAggregate() {
+		msgID = "aggregate"
+	}
+	var b strings.Builder
+	b.WriteString(pri + "1 ")
+	b.WriteString(time.Now().Format("2006-01-02T15:04:05.000000Z07:00"))
+	b.WriteString(" " + syslogToken(s.hostname) + " " + syslogToken(s.appName) + " " + s.pid + " " + msgID + " ")
+	b.WriteString(syslogStructuredData("http@"+strconv.Itoa(s.enterpriseID), fields))
+	meta := append(syslogSortedPairs(e.StaticFields()), syslogSortedPairs(e.Tags())...)
+	if len(meta) > 0 {
+		b.WriteString(syslogStructuredData("meta@"+strconv.Itoa(s.enterpriseID), meta))
+	}
+	b.WriteString(" " + e.Message())
+	return b.String()
+}
+
+// syslogFields returns the HTTP fields of an entry, or the window and fields of a summary, as ordered key/value pairs.
+func syslogFields(e Entry) [][2]string {
+	if e.IsSummary() {
+		fields := [][2]string{
+			{"windowStart", e.Time().Format(time.RFC3339)},
+			{"interval", e.Resolution().String()},
+		}
+		for _, attr := range e.e.summary {
+			fields = append(fields, [2]string{attr.Key, attr.Value.String()})
+		}
+		return fields
+	}
+	fields := [][2]string{
+		{"method", e.Method()},
+		{"status", strconv.Itoa(e.StatusCode())},
+		{"ip", e.IP()},
+		{"ua", e.UserAgent()},
+		{"proto", e.Proto()},
+	}
+	if e.AggregatePath() != "" {
+		fields = append(fields, [2]string{"aggregatePath", e.AggregatePath()})
+	}
+	if isBot, detected := e.IsBot(); detected {
+		fields = append(fields, [2]string{"isBot", strconv.FormatBool(isBot)})
+	}
+	if e.IsAggregate() {
+		return append(fields,
+			[2]string{"count", strconv.Itoa(e.Count())},
+			[2]string{"meanLatency", e.Latency().String()},
+			[2]string{"minLatency", e.MinLatency().String()},
+			[2]string{"maxLatency", e.MaxLatency().String()},
+			[2]string{"sumSizeRespBody", strconv.Itoa(e.ResponseSize())},
+			[2]string{"slowCount", strconv.Itoa(e.SlowCount())},
+		)
+	}
+	fields = append(fields,
+		[2]string{"path", e.Path()},
+		[2]string{"latency", e.Latency().String()},
+		[2]string{"responseSize", strconv.Itoa(e.ResponseSize())},
+	)
+	if e.Referer() != "" {
+		fields = append(fields, [2]string{"referer", e.Referer()})
+	}
+	if e.IsSlow() {
+		fields = append(fields, [2]string{"slow", "true"})
+	}
+	for _, k := range slices.Sorted(maps.Keys(e.Fields())) {
+		fields = append(fields, [2]string{k, e.Fields()[k]})
+	}
+	return fields
+}
+
+// syslogSortedPairs returns the pairs of a map sorted by key.
+func syslogSortedPairs(m map[string]string) [][2]string {
+	pairs := make([][2]string, 0, len(m))
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		pairs = append(pairs, [2]string{k, m[k]})
+	}
+	return pairs
+}
+
+// syslogStructuredData renders an RFC 5424 SD-ELEMENT.
+func syslogStructuredData(id string, params [][2]string) string {
+	var b strings.Builder
+	b.WriteString("[" + id)
+	for _, kv := range params {
+		b.WriteString(" " + syslogParamName(kv[0]) + `="` + syslogParamValue.Replace(kv[1]) + `"`)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// syslogParamValue escapes the characters RFC 5424 requires to be escaped in PARAM-VALUE.
+var syslogParamValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogParamName keeps a SD-NAME within 32 printable characters, excluding '=', ' ', ']' and '"'.
+func syslogParamName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r <= 32 || r >= 127 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// syslogToken returns a header field value, or the NILVALUE "-" when empty. Spaces are not allowed.
+func syslogToken(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, " ", "_")
+}
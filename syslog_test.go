@@ -0,0 +1,190 @@
+package slogger
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFrame reads an octet-counted syslog frame from a stream.
+func readFrame(t *testing.T, conn net.Conn, r *bufio.Reader) string {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	length, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatalf("no frame received: %v", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(length))
+	if err != nil {
+		t.Fatalf("invalid frame length %q", length)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func realtimeEntry() Entry {
+	return newEntry("api_logger v1", logEntry{
+		method:          "GET",
+		statusCode:      500,
+		ip:              "10.0.0.1",
+		ua:              "curl/8.0",
+		proto:           "HTTP/1.1",
+		level:           slog.LevelError,
+		realtimeDetails: realtimeDetails{path: `/a"b]`, latency: 20 * time.Millisecond},
+	}, configure(WithStaticLogEntries(map[string]string{"service": "shop"})))
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []SyslogOption
+		prefix   string
+		contains []string
+	}{
+		{"RFC5424", []SyslogOption{SyslogWithFacility(FacilityLocal3), SyslogAppName("shop"), SyslogHostname("host1")}, "<155>1 ", []string{
+			" host1 shop ",
+			" access [http@32473 method=\"GET\" status=\"500\" ip=\"10.0.0.1\" ua=\"curl/8.0\" proto=\"HTTP/1.1\" path=\"/a\\\"b\\]\"",
+			"[meta@32473 service=\"shop\"] api_logger v1",
+		}},
+		{"RFC3164", []SyslogOption{SyslogWithFormat(SyslogRFC3164), SyslogAppName("shop"), SyslogHostname("host1")}, "<131>", []string{
+			" host1 shop[",
+			"]: api_logger v1 method=\"GET\" status=\"500\"",
+			" service=\"shop\"",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pc := listenUDP(t)
+			s, err := NewSyslogSink("udp", pc.LocalAddr().String(), test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			if err := s.Emit(realtimeEntry()); err != nil {
+				t.Fatal(err)
+			}
+			got := readPacket(t, pc)
+			if !strings.HasPrefix(got, test.prefix) {
+				t.Errorf("expected prefix %q, got %q", test.prefix, got)
+			}
+			for _, c := range test.contains {
+				if !strings.Contains(got, c) {
+					t.Errorf("expected %q in %q", c, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		expected int
+	}{
+		{slog.LevelDebug, 7},
+		{slog.LevelInfo, 6},
+		{slog.LevelWarn, 4},
+		{slog.LevelError, 3},
+		{slog.LevelError + 4, 3},
+	}
+	for _, test := range tests {
+		if got := syslogSeverity(test.level); got != test.expected {
+			t.Errorf("level %v: expected %d, got %d", test.level, test.expected, got)
+		}
+	}
+}
+
+func TestSyslogSinkTCPReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	s, err := NewSyslogSink("tcp", ln.Addr().String(), SyslogWithFormat(SyslogRFC3164))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	first, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Emit(aggregatedEntry()); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFrame(t, first, bufio.NewReader(first)); !strings.Contains(got, `count="4"`) {
+		t.Errorf("unexpected frame %q", got)
+	}
+
+	// The server drops the connection: writes fail once the peer reset is noticed, then the sink reconnects.
+	first.Close()
+	var second net.Conn
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for second == nil && time.Now().Before(deadline) {
+		_ = s.Emit(aggregatedEntry())
+		select {
+		case second = <-accepted:
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	if second == nil {
+		t.Fatal("expected the sink to reconnect")
+	}
+	defer second.Close()
+	if got := readFrame(t, second, bufio.NewReader(second)); !strings.Contains(got, `count="4"`) {
+		t.Errorf("unexpected frame after reconnect %q", got)
+	}
+}
+
+func TestSyslogSinkUnix(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "syslog.sock")
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	s, err := NewSyslogSink("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := s.Emit(realtimeEntry()); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFrame(t, conn, bufio.NewReader(conn)); !strings.HasPrefix(got, "<131>1 ") {
+		t.Errorf("unexpected frame %q", got)
+	}
+}
+
+func TestNewSyslogSinkUnsupportedNetwork(t *testing.T) {
+	if _, err := NewSyslogSink("http", "127.0.0.1:514"); err == nil {
+		t.Error("expected an error for an unsupported network")
+	}
+}
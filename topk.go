@@ -0,0 +1,212 @@
+package slogger
+
+import (
+	"container/heap"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// topKCapacityFactor is the number of counters a Space-Saving sketch keeps per reported key. Any key seen more than
+// 1/(topKCapacityFactor·k) of the requests of a window is guaranteed to be tracked.
+const topKCapacityFactor = 8
+
+// WithTopK enables a summary line per aggregation window with the k most frequent client IPs, aggregate paths,
+// user agents and error routes (status >= 400). The counts are estimated with Space-Saving sketches of 8·k keys,
+// so memory does not grow with the number of distinct clients. The summary goes through the entry hooks, the schema
+// and the sinks like the buckets of the window, and Entry.IsSummary reports it. Zero disables it.
+func WithTopK(k int) Option {
+	return func(c *conf) {
+		c.topK = k
+	}
+}
+
+// HeavyHitter is a frequent key of a window and its estimated count. The count may be overestimated by at most Error.
+type HeavyHitter struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+	Error int    `json:"error,omitempty"`
+}
+
+// String returns the quoted key and its count, eg: "10.0.0.1"=42.
+func (h HeavyHitter) String() string {
+	return strconv.Quote(h.Key) + "=" + strconv.Itoa(h.Count)
+}
+
+// TopK are the heavy hitters of a window, most frequent first. It is empty unless WithTopK is used.
+type TopK struct {
+	IPs         []HeavyHitter `json:"ips,omitempty"`
+	Paths       []HeavyHitter `json:"paths,omitempty"`
+	UserAgents  []HeavyHitter `json:"userAgents,omitempty"`
+	ErrorRoutes []HeavyHitter `json:"errorRoutes,omitempty"`
+}
+
+// heavyHitters tracks the frequent keys of a window.
+type heavyHitters struct {
+	k           int
+	ips         *spaceSaving
+	paths       *spaceSaving
+	userAgents  *spaceSaving
+	errorRoutes *spaceSaving
+}
+
+// newHeavyHitters returns the sketches reporting the k most frequent keys, or nil when k is not positive.
+func newHeavyHitters(k int) *heavyHitters {
+	if k <= 0 {
+		return nil
+	}
+	capacity := k * topKCapacityFactor
+	return &heavyHitters{
+		k:           k,
+		ips:         newSpaceSaving(capacity),
+		paths:       newSpaceSaving(capacity),
+		userAgents:  newSpaceSaving(capacity),
+		errorRoutes: newSpaceSaving(capacity),
+	}
+}
+
+// add counts a request.
+func (h *heavyHitters) add(st logEntry) {
+	if h == nil {
+		return
+	}
+	h.ips.add(st.ip)
+	h.paths.add(st.aggregatePath)
+	h.userAgents.add(st.ua)
+	if st.statusCode >= 400 {
+		h.errorRoutes.add(st.method + " " + st.aggregatePath)
+	}
+}
+
+// merge adds the counts of other, eg: to build a rollup window.
+func (h *heavyHitters) merge(other *heavyHitters) {
+	if h == nil || other == nil {
+		return
+	}
+	h.ips.merge(other.ips)
+	h.paths.merge(other.paths)
+	h.userAgents.merge(other.userAgents)
+	h.errorRoutes.merge(other.errorRoutes)
+}
+
+// top returns the k most frequent keys of each sketch.
+func (h *heavyHitters) top() TopK {
+	if h == nil {
+		return TopK{}
+	}
+	return TopK{
+		IPs:         h.ips.top(h.k),
+		Paths:       h.paths.top(h.k),
+		UserAgents:  h.userAgents.top(h.k),
+		ErrorRoutes: h.errorRoutes.top(h.k),
+	}
+}
+
+// spaceSaving is a Space-Saving sketch: it keeps a fixed number of counters and, when a new key arrives while full,
+// reassigns the smallest counter to it, recording the previous count as the maximum overestimation.
+type spaceSaving struct {
+	capacity int
+	counters map[string]*ssCounter
+	heap     ssHeap
+}
+
+type ssCounter struct {
+	key   string
+	count int
+	err   int
+	index int
+}
+
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{capacity: capacity, counters: make(map[string]*ssCounter, capacity)}
+}
+
+// add counts an occurrence of key.
+func (s *spaceSaving) add(key string) {
+	s.addCount(key, 1, 0)
+}
+
+// addCount counts n occurrences of key, overestimated by at most err.
+func (s *spaceSaving) addCount(key string, n, err int) {
+	if c, ok := s.counters[key]; ok {
+		c.count += n
+		c.err += err
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if len(s.heap) < s.capacity {
+		c := &ssCounter{key: key, count: n, err: err}
+		s.counters[key] = c
+		heap.Push(&s.heap, c)
+		return
+	}
+	c := s.heap[0]
+	delete(s.counters, c.key)
+	c.key, c.err = key, c.count+err
+	c.count += n
+	s.counters[key] = c
+	heap.Fix(&s.heap, 0)
+}
+
+// merge adds the counters of other.
+func (s *spaceSaving) merge(other *spaceSaving) {
+	for _, c := range other.heap {
+		s.addCount(c.key, c.count, c.err)
+	}
+}
+
+// top returns the k keys with the highest counts, most frequent first.
+func (s *spaceSaving) top(k int) []HeavyHitter {
+	hitters := make([]HeavyHitter, 0, len(s.heap))
+	for _, c := range s.heap {
+		hitters = append(hitters, HeavyHitter{Key: c.key, Count: c.count, Error: c.err})
+	}
+	slices.SortFunc(hitters, func(x, y HeavyHitter) int {
+		if x.Count != y.Count {
+			return y.Count - x.Count
+		}
+		return strings.Compare(x.Key, y.Key)
+	})
+	return hitters[:min(k, len(hitters))]
+}
+
+// ssHeap is a min-heap of counters by count.
+type ssHeap []*ssCounter
+
+func (h ssHeap) Len() int           { return len(h) }
+func (h ssHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h ssHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *ssHeap) Push(x any) {
+	c := x.(*ssCounter)
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *ssHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// printTopK emits the summary line of a window.
+func (a *Logger) printTopK(w *windowState, duration time.Duration, requests int) {
+	if requests == 0 {
+		return
+	}
+	top := w.top.top()
+	a.printSummary("api_logger v1 top", slog.LevelInfo, w, duration,
+		slog.Int("requests", requests),
+		slog.Any("topIPs", top.IPs),
+		slog.Any("topPaths", top.Paths),
+		slog.Any("topUserAgents", top.UserAgents),
+		slog.Any("topErrorRoutes", top.ErrorRoutes),
+	)
+}
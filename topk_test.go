@@ -0,0 +1,176 @@
+package slogger
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpaceSaving(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		keys     func(add func(string))
+		want     []HeavyHitter
+	}{
+		{
+			name:     "exact below capacity",
+			capacity: 4,
+			keys: func(add func(string)) {
+				for _, k := range []string{"a", "b", "a", "c", "a", "b"} {
+					add(k)
+				}
+			},
+			want: []HeavyHitter{{Key: "a", Count: 3}, {Key: "b", Count: 2}},
+		},
+		{
+			name:     "heavy hitters survive a flood of distinct keys",
+			capacity: 16,
+			keys: func(add func(string)) {
+				for i := 0; i < 10000; i++ {
+					add("scanner-" + strconv.Itoa(i))
+					if i%4 == 0 {
+						add("heavy")
+					}
+					if i%10 == 0 {
+						add("medium")
+					}
+				}
+			},
+			want: []HeavyHitter{{Key: "heavy"}, {Key: "medium"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSpaceSaving(tt.capacity)
+			tt.keys(s.add)
+			if len(s.counters) > tt.capacity || len(s.heap) > tt.capacity {
+				t.Fatalf("expected at most %d counters, got %d", tt.capacity, len(s.counters))
+			}
+			got := s.top(len(tt.want))
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d hitters, got %v", len(tt.want), got)
+			}
+			for i, h := range got {
+				if h.Key != tt.want[i].Key {
+					t.Errorf("expected %q at rank %d, got %v", tt.want[i].Key, i, got)
+				}
+				if tt.want[i].Count > 0 && (h.Count != tt.want[i].Count || h.Error != 0) {
+					t.Errorf("expected exact count %d, got %+v", tt.want[i].Count, h)
+				}
+			}
+		})
+	}
+}
+
+func TestTopKSummary(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var buf lockedBuffer
+	windows := make(chan struct{}, 10)
+	logger := New(ctx, WithTimeAggregation(50*time.Millisecond), WithTopK(2),
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithAfterEntry(func(e Entry) {
+			select {
+			case windows <- struct{}{}:
+			default:
+			}
+		}))
+
+	send := func(ip, path string, status, n int) {
+		for i := 0; i < n; i++ {
+			logger.send(logEntry{ip: ip, ua: "curl", method: http.MethodGet, aggregatePath: path, statusCode: status})
+		}
+	}
+	send("10.0.0.1", "/api", http.StatusOK, 5)
+	send("10.0.0.2", "/login", http.StatusUnauthorized, 3)
+	send("10.0.0.3", "/api", http.StatusOK, 1)
+
+	top := logger.Snapshot().Current.Top
+	if len(top.IPs) != 2 || top.IPs[0] != (HeavyHitter{Key: "10.0.0.1", Count: 5}) || top.IPs[1].Key != "10.0.0.2" {
+		t.Errorf("unexpected top IPs %v", top.IPs)
+	}
+	if len(top.ErrorRoutes) != 1 || top.ErrorRoutes[0] != (HeavyHitter{Key: "GET /login", Count: 3}) {
+		t.Errorf("unexpected top error routes %v", top.ErrorRoutes)
+	}
+	<-windows
+
+	var summary struct {
+		Msg      string        `json:"msg"`
+		Requests int           `json:"requests"`
+		TopPaths []HeavyHitter `json:"topPaths"`
+		TopUAs   []HeavyHitter `json:"topUserAgents"`
+	}
+	deadline := time.Now().Add(time.Second)
+	for summary.Msg == "" && time.Now().Before(deadline) {
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if strings.Contains(line, `"api_logger v1 top"`) {
+				if err := json.Unmarshal([]byte(line), &summary); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if summary.Requests != 9 {
+		t.Fatalf("expected a summary of 9 requests, got %+v in %s", summary, buf.String())
+	}
+	if len(summary.TopPaths) != 2 || summary.TopPaths[0] != (HeavyHitter{Key: "/api", Count: 6}) {
+		t.Errorf("unexpected top paths %v", summary.TopPaths)
+	}
+	if len(summary.TopUAs) != 1 || summary.TopUAs[0].Count != 9 {
+		t.Errorf("unexpected top user agents %v", summary.TopUAs)
+	}
+}
+
+func TestTopKDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := New(ctx, WithTimeAggregation(time.Hour), WithDefaultOutput(false))
+	logger.send(logEntry{ip: "10.0.0.1", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	if top := logger.Snapshot().Current.Top; top.IPs != nil || top.Paths != nil {
+		t.Errorf("expected no heavy hitters, got %+v", top)
+	}
+}
+
+func TestTopKSummaryThroughSinks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var entries []Entry
+	logger := New(ctx, WithDefaultOutput(false), WithSchema(ECSSchema()),
+		WithOnEntry(func(e *Entry) { e.SetTag("env", "test") }),
+		WithSink(SinkFunc(func(e Entry) error {
+			entries = append(entries, e)
+			return nil
+		})))
+	start := time.Date(2025, time.September, 11, 3, 34, 0, 0, time.UTC)
+	w := &windowState{start: start, top: newHeavyHitters(1)}
+	w.top.add(logEntry{ip: "10.0.0.1", ua: "curl", method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK})
+	logger.printTopK(w, time.Minute, 9)
+	if err := logger.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected the summary to reach the sink with the default output disabled, got %d entries", len(entries))
+	}
+	e := entries[0]
+	if !e.IsSummary() || !e.IsAggregate() || e.Count() != 0 || e.Message() != "api_logger v1 top" || e.Tags()["env"] != "test" {
+		t.Errorf("unexpected summary entry %+v", e)
+	}
+	attrs := map[string]string{}
+	for _, attr := range logAttrs(e) {
+		attrs[attr.Key] = attr.Value.String()
+	}
+	if attrs["event.start"] != "2025-09-11T03:34:00Z" || attrs["labels.interval"] != "1m0s" || attrs["requests"] != "9" || attrs["env"] != "test" {
+		t.Errorf("expected the schema to render the summary window, got %v", attrs)
+	}
+}
@@ -0,0 +1,160 @@
+package slogger
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultMaxUniqueRoutes limits the number of routes with sketches in a window when WithMaxAggregationKeys is not set.
+const defaultMaxUniqueRoutes = 256
+
+// WithUniqueClients estimates, per method and aggregate path, the number of distinct client IPs and of distinct
+// IP and user agent pairs of each aggregation window with HyperLogLog sketches of the given precision, eg:
+// DefaultHyperLogLogPrecision. A summary line per route with uniqueClients and uniqueClientAgents is emitted at the end
+// of the window, and the sketches are available in Snapshot. Zero disables it. The number of routes is limited by
+// WithMaxAggregationKeys, or to 256 without it; the routes beyond the limit share a route whose method and aggregate
+// path are "__overflow__".
+func WithUniqueClients(precision int) Option {
+	return func(c *conf) {
+		c.uniqueClientsPrecision = precision
+	}
+}
+
+// RouteStats are the distinct clients of a route in a window.
+type RouteStats struct {
+	Method        string
+	AggregatePath string
+	Requests      int
+	// UniqueClients is the estimated number of distinct client IPs.
+	UniqueClients uint64
+	// UniqueClientAgents is the estimated number of distinct client IP and user agent pairs.
+	UniqueClientAgents uint64
+	// Clients and ClientAgents are copies of the sketches, which can be merged across windows and Loggers.
+	Clients      *HyperLogLog
+	ClientAgents *HyperLogLog
+}
+
+// routeClients holds the sketches of a route.
+type routeClients struct {
+	method        string
+	aggregatePath string
+	requests      int
+	clients       *HyperLogLog
+	clientAgents  *HyperLogLog
+}
+
+// uniqueClients holds the sketches of the routes of a window.
+type uniqueClients struct {
+	precision int
+	// maxRoutes is the number of routes beyond which new routes are folded into the overflow route.
+	maxRoutes int
+	routes    map[string]*routeClients
+}
+
+// newUniqueClients returns the sketches of a window with at most maxRoutes routes, or defaultMaxUniqueRoutes when
+// maxRoutes is zero. It returns nil when precision is not valid.
+func newUniqueClients(precision, maxRoutes int) *uniqueClients {
+	if precision < MinHyperLogLogPrecision || precision > MaxHyperLogLogPrecision {
+		return nil
+	}
+	if maxRoutes <= 0 {
+		maxRoutes = defaultMaxUniqueRoutes
+	}
+	return &uniqueClients{precision: precision, maxRoutes: maxRoutes, routes: make(map[string]*routeClients)}
+}
+
+// route returns the sketches of a route, creating them if needed. Once maxRoutes routes exist, new routes are folded
+// into the overflow route, which is not counted in the limit.
+func (u *uniqueClients) route(method, aggregatePath string) *routeClients {
+	key := method + " " + aggregatePath
+	if r, ok := u.routes[key]; ok {
+		return r
+	}
+	limit := u.maxRoutes
+	if _, ok := u.routes[overflowKey+" "+overflowKey]; ok {
+		limit++
+	}
+	if len(u.routes) >= limit {
+		method, aggregatePath = overflowKey, overflowKey
+		key = method + " " + aggregatePath
+		if r, ok := u.routes[key]; ok {
+			return r
+		}
+	}
+	clients, _ := NewHyperLogLog(u.precision)
+	clientAgents, _ := NewHyperLogLog(u.precision)
+	r := &routeClients{method: method, aggregatePath: aggregatePath, clients: clients, clientAgents: clientAgents}
+	u.routes[key] = r
+	return r
+}
+
+// add counts the client of a request.
+func (u *uniqueClients) add(st logEntry) {
+	if u == nil {
+		return
+	}
+	r := u.route(st.method, st.aggregatePath)
+	r.requests++
+	r.clients.Add(st.ip)
+	r.clientAgents.Add(st.ip + "\x00" + st.ua)
+}
+
+// merge adds the sketches of other, eg: to build a rollup window. Sketches of a different precision are skipped.
+func (u *uniqueClients) merge(other *uniqueClients) {
+	if u == nil || other == nil || u.precision != other.precision {
+		return
+	}
+	for _, o := range other.routes {
+		r := u.route(o.method, o.aggregatePath)
+		r.requests += o.requests
+		_ = r.clients.Merge(o.clients)
+		_ = r.clientAgents.Merge(o.clientAgents)
+	}
+}
+
+// stats returns a copy of the sketches, routes with the most requests first.
+func (u *uniqueClients) stats() []RouteStats {
+	if u == nil {
+		return nil
+	}
+	routes := make([]RouteStats, 0, len(u.routes))
+	for _, r := range u.routes {
+		routes = append(routes, RouteStats{
+			Method:             r.method,
+			AggregatePath:      r.aggregatePath,
+			Requests:           r.requests,
+			UniqueClients:      r.clients.Estimate(),
+			UniqueClientAgents: r.clientAgents.Estimate(),
+			Clients:            r.clients.Clone(),
+			ClientAgents:       r.clientAgents.Clone(),
+		})
+	}
+	slices.SortFunc(routes, func(x, y RouteStats) int {
+		if x.Requests != y.Requests {
+			return y.Requests - x.Requests
+		}
+		if c := strings.Compare(x.AggregatePath, y.AggregatePath); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Method, y.Method)
+	})
+	return routes
+}
+
+// printUniqueClients emits a summary line with the distinct clients of each route of a window.
+func (a *Logger) printUniqueClients(w *windowState, duration time.Duration) {
+	if w.unique == nil {
+		return
+	}
+	for _, r := range w.unique.routes {
+		a.printSummary("api_logger v1 unique", slog.LevelInfo, w, duration,
+			slog.String("method", r.method),
+			slog.String("aggregatePath", r.aggregatePath),
+			slog.Int("requests", r.requests),
+			slog.Uint64("uniqueClients", r.clients.Estimate()),
+			slog.Uint64("uniqueClientAgents", r.clientAgents.Estimate()),
+		)
+	}
+}
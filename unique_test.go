@@ -0,0 +1,95 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUniqueClients(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var buf lockedBuffer
+	logger := New(ctx, WithTimeAggregation(time.Hour), WithUniqueClients(DefaultHyperLogLogPrecision), WithMaxAggregationKeys(5), WithQueueSize(200),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	for i := 0; i < 100; i++ {
+		ip := "10.0.0." + strconv.Itoa(i%40)
+		logger.send(logEntry{ip: ip, ua: "ua-" + strconv.Itoa(i%2), method: http.MethodGet, aggregatePath: "/api", statusCode: http.StatusOK})
+	}
+	logger.send(logEntry{ip: "10.0.0.1", ua: "ua-0", method: http.MethodPost, aggregatePath: "/api", statusCode: http.StatusCreated})
+
+	routes := logger.Snapshot().Current.Routes
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %+v", routes)
+	}
+	// Clients folded into overflow buckets are still counted.
+	if r := routes[0]; r.Method != http.MethodGet || r.Requests != 100 || r.UniqueClients != 40 || r.UniqueClientAgents != 40 {
+		t.Errorf("unexpected route %+v", r)
+	}
+	if r := routes[1]; r.Method != http.MethodPost || r.Requests != 1 || r.UniqueClients != 1 {
+		t.Errorf("unexpected route %+v", r)
+	}
+
+	merged := routes[0].Clients.Clone()
+	if err := merged.Merge(routes[1].Clients); err != nil || merged.Estimate() != 40 {
+		t.Errorf("expected 40 distinct clients across routes, got %d (%v)", merged.Estimate(), err)
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for strings.Count(buf.String(), "api_logger v1 unique") < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if out := buf.String(); !strings.Contains(out, "method=GET aggregatePath=/api requests=100 uniqueClients=40 uniqueClientAgents=40") {
+		t.Errorf("expected a unique clients line, got %s", out)
+	}
+}
+
+func TestUniqueClientsBoundedRoutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxKeys  int
+		expected int
+	}{
+		{"MaxAggregationKeys", 10, 10},
+		{"Default", 0, defaultMaxUniqueRoutes},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := newUniqueClients(MinHyperLogLogPrecision, test.maxKeys)
+			for i := 0; i < 1000; i++ {
+				u.add(logEntry{ip: "10.0.0.1", method: "M" + strconv.Itoa(i), aggregatePath: "error_4xx"})
+			}
+			if len(u.routes) != test.expected+1 {
+				t.Errorf("expected %d routes and the overflow route, got %d", test.expected, len(u.routes))
+			}
+			overflow := u.routes[overflowKey+" "+overflowKey]
+			if overflow == nil || overflow.requests != 1000-test.expected {
+				t.Errorf("expected %d requests in the overflow route, got %+v", 1000-test.expected, overflow)
+			}
+
+			rollup := newUniqueClients(MinHyperLogLogPrecision, test.maxKeys)
+			rollup.merge(u)
+			rollup.merge(u)
+			if len(rollup.routes) != test.expected+1 {
+				t.Errorf("expected merged routes to stay bounded, got %d", len(rollup.routes))
+			}
+		})
+	}
+}
+
+func TestUniqueClientsDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := New(ctx, WithTimeAggregation(time.Hour), WithDefaultOutput(false))
+	logger.send(logEntry{ip: "10.0.0.1", method: http.MethodGet, aggregatePath: "/", statusCode: http.StatusOK})
+	if routes := logger.Snapshot().Current.Routes; routes != nil {
+		t.Errorf("expected no routes, got %+v", routes)
+	}
+}
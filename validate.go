@@ -0,0 +1,108 @@
+package slogger
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ConflictError reports options that overwrite each other, eg: WithAggregatePath and WithPathAggregator.
+type ConflictError struct {
+	Options []string
+	Reason  string
+}
+
+// Error returns the conflicting options and the reason.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("slogger: conflicting options %s: %s", strings.Join(e.Options, " and "), e.Reason)
+}
+
+// validate checks the final configuration. It returns every ValidationError and ConflictError found, joined with errors.Join.
+func (c *conf) validate() error {
+	var errs []error
+	invalid := func(option string, value any, reason string) {
+		errs = append(errs, &ValidationError{Field: option, Value: value, Reason: reason})
+	}
+
+	if c.aggregationQueueSize <= 0 {
+		invalid("WithQueueSize", c.aggregationQueueSize, "must be positive")
+	}
+	if c.aggregationInterval <= 0 {
+		invalid("WithTimeAggregation", c.aggregationInterval, "must be positive")
+	}
+	for _, interval := range c.rollupIntervals {
+		if c.aggregationInterval > 0 && (interval <= c.aggregationInterval || interval%c.aggregationInterval != 0) {
+			invalid("WithTimeAggregation", interval, "rollup must be a larger multiple of "+c.aggregationInterval.String())
+		}
+	}
+	if len(slices.Compact(slices.Clone(c.rollupIntervals))) != len(c.rollupIntervals) {
+		invalid("WithTimeAggregation", c.rollupIntervals, "rollups must be distinct")
+	}
+	if c.checkpointPath != "" && c.checkpointInterval <= 0 {
+		invalid("WithCheckpoint", c.checkpointInterval, "must be positive")
+	}
+	if c.snapshotHistory < 0 {
+		invalid("WithSnapshotHistory", c.snapshotHistory, "must not be negative")
+	}
+	if c.maxAggregationKeys < 0 {
+		invalid("WithMaxAggregationKeys", c.maxAggregationKeys, "must not be negative")
+	}
+	if p := c.uniqueClientsPrecision; p != 0 && (p < MinHyperLogLogPrecision || p > MaxHyperLogLogPrecision) {
+		invalid("WithUniqueClients", p, fmt.Sprintf("must be 0 or between %d and %d", MinHyperLogLogPrecision, MaxHyperLogLogPrecision))
+	}
+	if c.requestBodyLimit < 0 {
+		invalid("WithLogRequestBody", c.requestBodyLimit, "must not be negative")
+	}
+	if c.topK < 0 {
+		invalid("WithTopK", c.topK, "must not be negative")
+	}
+	if c.pathMappingFunction == nil {
+		invalid("WithAggregatePath", nil, "must not be nil")
+	}
+	if setBy := slices.Compact(slices.Sorted(slices.Values(c.pathMappingSetBy))); len(setBy) > 1 {
+		errs = append(errs, &ConflictError{Options: setBy, Reason: "both set the path aggregation function"})
+	}
+	if c.slowThreshold < 0 {
+		invalid("WithSlowThreshold", c.slowThreshold, "must not be negative")
+	}
+	for route, threshold := range c.slowRouteThresholds {
+		if threshold <= 0 {
+			invalid("WithSlowThreshold", route+"="+threshold.String(), "must be positive")
+		}
+	}
+	if ratio := c.globalSampleRatio(); ratio < 0 || ratio > 1 {
+		invalid("WithSampleRatio", ratio, "must be between 0 and 1")
+	}
+	for route, ratio := range c.routeSampleRatios {
+		if ratio < 0 || ratio > 1 {
+			invalid("WithRouteSampleRatios", fmt.Sprintf("%s=%v", route, ratio), "must be between 0 and 1")
+		}
+	}
+	if c.rateLimiter != nil && c.rateLimiter.rate <= 0 {
+		invalid("WithRateLimit", c.rateLimiter.rate, "must be positive")
+	}
+	if c.async != nil {
+		if c.async.bufferSize <= 0 {
+			invalid("AsyncBufferSize", c.async.bufferSize, "must be positive")
+		}
+		if c.async.workers <= 0 {
+			invalid("AsyncWorkers", c.async.workers, "must be positive")
+		}
+		if c.async.batchSize <= 0 {
+			invalid("AsyncBatchSize", c.async.batchSize, "must be positive")
+		}
+		if c.async.policy != OverflowDrop && c.async.policy != OverflowBlock {
+			invalid("AsyncOverflowPolicy", c.async.policy, "must be OverflowDrop or OverflowBlock")
+		}
+	}
+	for _, w := range c.sinks {
+		if w.sink == nil {
+			invalid("WithSink", nil, "must not be nil")
+		}
+		if w.bufferSize <= 0 {
+			invalid("SinkBufferSize", w.bufferSize, "must be positive")
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewWithError(t *testing.T) {
+	pathFunc := func(route, path string, statusCode int) string { return route }
+
+	tests := []struct {
+		name             string
+		options          []Option
+		expectedInvalid  []string
+		expectedConflict bool
+	}{
+		{"Defaults", nil, nil, false},
+		{"ValidOptions", []Option{WithTimeAggregation(time.Second), WithQueueSize(10), WithAggregatePath(pathFunc), WithSampleRatio(0.5)}, nil, false},
+		{"ZeroQueueSize", []Option{WithQueueSize(0)}, []string{"WithQueueSize"}, false},
+		{"NegativeQueueSize", []Option{WithQueueSize(-1)}, []string{"WithQueueSize"}, false},
+		{"ZeroInterval", []Option{WithTimeAggregation(0)}, []string{"WithTimeAggregation"}, false},
+		{"NegativeMaxAggregationKeys", []Option{WithMaxAggregationKeys(-1)}, []string{"WithMaxAggregationKeys"}, false},
+		{"UniqueClientsPrecisionOutOfRange", []Option{WithUniqueClients(20)}, []string{"WithUniqueClients"}, false},
+		{"ValidRollups", []Option{WithTimeAggregation(10*time.Second, time.Hour, time.Minute)}, nil, false},
+		{"InvalidRollups", []Option{WithTimeAggregation(10*time.Second, 15*time.Second, 5*time.Second, time.Minute, time.Minute)}, []string{"WithTimeAggregation", "WithTimeAggregation", "WithTimeAggregation"}, false},
+		{"ZeroCheckpointInterval", []Option{WithCheckpoint("aggregator.json", 0)}, []string{"WithCheckpoint"}, false},
+		{"NegativeTopK", []Option{WithTopK(-1)}, []string{"WithTopK"}, false},
+		{"NilPathFunction", []Option{WithPathAggregator(nil)}, []string{"WithAggregatePath"}, false},
+		{"PathFunctionConflict", []Option{WithAggregatePath(pathFunc), WithPathAggregator(pathFunc)}, nil, true},
+		{"SamePathOptionTwice", []Option{WithAggregatePath(pathFunc), WithAggregatePath(pathFunc)}, nil, false},
+		{"NegativeSlowThreshold", []Option{WithSlowThreshold(-time.Second, map[string]time.Duration{"/a": 0})}, []string{"WithSlowThreshold", "WithSlowThreshold"}, false},
+		{"SampleRatioOutOfRange", []Option{WithSampleRatio(2), WithRouteSampleRatios(map[string]float64{"/a": -0.5})}, []string{"WithSampleRatio", "WithRouteSampleRatios"}, false},
+		{"ZeroRateLimit", []Option{WithRateLimit(0, 1, SampleByRoute)}, []string{"WithRateLimit"}, false},
+		{"InvalidAsync", []Option{WithAsync(AsyncBufferSize(0), AsyncWorkers(-1), AsyncBatchSize(0), AsyncOverflowPolicy(5))}, []string{"AsyncBufferSize", "AsyncWorkers", "AsyncBatchSize", "AsyncOverflowPolicy"}, false},
+		{"InvalidSink", []Option{WithSink(nil, SinkBufferSize(0))}, []string{"WithSink", "SinkBufferSize"}, false},
+		{"MultipleErrors", []Option{WithQueueSize(0), WithTimeAggregation(-time.Second), WithAggregatePath(pathFunc), WithPathAggregator(pathFunc)}, []string{"WithQueueSize", "WithTimeAggregation"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			logger, err := NewWithError(ctx, test.options...)
+			if len(test.expectedInvalid) == 0 && !test.expectedConflict {
+				if err != nil || logger == nil {
+					t.Fatalf("expected a logger, got error %v", err)
+				}
+				return
+			}
+			if err == nil || logger != nil {
+				t.Fatal("expected an error and no logger")
+			}
+
+			var invalid []string
+			var conflict bool
+			for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var ve *ValidationError
+				var ce *ConflictError
+				switch {
+				case errors.As(e, &ve):
+					invalid = append(invalid, ve.Field)
+				case errors.As(e, &ce):
+					conflict = true
+				}
+			}
+			if len(invalid) != len(test.expectedInvalid) {
+				t.Fatalf("expected invalid options %v, got %v", test.expectedInvalid, invalid)
+			}
+			for i := range invalid {
+				if invalid[i] != test.expectedInvalid[i] {
+					t.Errorf("expected invalid options %v, got %v", test.expectedInvalid, invalid)
+				}
+			}
+			if conflict != test.expectedConflict {
+				t.Errorf("expected conflict %v, got error %v", test.expectedConflict, err)
+			}
+		})
+	}
+}